@@ -2,8 +2,6 @@ package cache
 
 import (
 	"context"
-	"fmt"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
 	"github.com/hashicorp/terraform-plugin-go/tftypes"
@@ -19,6 +17,10 @@ func (s *RawProviderServer) ApplyResourceChange(ctx context.Context, req *tfprot
 		return resp, nil
 	}
 
+	// Commands and file snapshots stop when Terraform stops the provider, and their results are discarded.
+	ctx, cancel := s.stopContext(ctx)
+	defer cancel()
+
 	rt, err := GetResourceType(req.TypeName)
 	if err != nil {
 		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
@@ -62,40 +64,59 @@ func (s *RawProviderServer) ApplyResourceChange(ctx context.Context, req *tfprot
 		return resp, nil
 	}
 
-	switch {
-	case applyPriorState.IsNull():
-		// This is a "create"
-		// All we need to do is update the timestamp
-		timestamp := time.Now().Unix()
-		applyPlannedValue["timestamp"] = tftypes.NewValue(tftypes.String, fmt.Sprint(timestamp))
-
-		applyStateVal := tftypes.NewValue(applyPlannedState.Type(), applyPlannedValue)
-
-		s.logger.Trace("[ApplyResourceChange]", "[PropStateVal]", dump(applyStateVal))
+	if applyPlannedState.IsNull() {
+		// Delete the resource. Entries only live in the state, so there is nothing else to clean up.
+		resp.NewState = req.PlannedState
+		return resp, nil
+	}
 
-		plannedState, err := tfprotov5.NewDynamicValue(rt, applyStateVal)
+	applyConfigValue := make(map[string]tftypes.Value)
+	if req.Config != nil {
+		applyConfig, err := req.Config.Unmarshal(rt)
 		if err != nil {
 			resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
 				Severity: tfprotov5.DiagnosticSeverityError,
-				Summary:  "Failed to assemble proposed state during apply",
+				Summary:  "Failed to unmarshal resource configuration",
 				Detail:   err.Error(),
 			})
 			return resp, nil
 		}
-		s.logger.Trace("[ApplyResourceChange]", "[PlannedState]", dump(plannedState))
+		_ = applyConfig.As(&applyConfigValue)
+	}
 
-		resp.NewState = &plannedState
-	case !applyPlannedState.IsNull() && !applyPriorState.IsNull():
+	switch req.TypeName {
+	case "cache_version_pin":
+		s.applyVersionPin(applyPriorState, applyPlannedValue)
+	case "cache_file":
+		s.applyFile(ctx, applyPlannedValue, resp)
+	case "cache_command":
+		s.applyCommand(ctx, applyPlannedValue, resp)
+	case "cache_env":
+		s.applyEnv(applyPriorState, applyPlannedValue, resp)
+	default:
+		s.applyStore(applyPriorState, applyPlannedValue, applyConfigValue, resp)
+	}
+	if !hasErrors(resp.Diagnostics) {
+		resp.Diagnostics = append(resp.Diagnostics, stoppedDiagnostic(ctx)...)
+	}
+	if hasErrors(resp.Diagnostics) {
+		return resp, nil
+	}
+
+	applyStateVal := tftypes.NewValue(rt, applyPlannedValue)
+	s.logger.Trace("[ApplyResourceChange]", "[PropStateVal]", dump(applyStateVal))
+
+	newState, err := tfprotov5.NewDynamicValue(rt, applyStateVal)
+	if err != nil {
 		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
 			Severity: tfprotov5.DiagnosticSeverityError,
-			Summary:  "Attempting to perform update on cache resource",
-			Detail:   "An update operation was attempted on a cache resource. This should not occur. Please report this to the provider maintainers.",
+			Summary:  "Failed to assemble proposed state during apply",
+			Detail:   err.Error(),
 		})
 		return resp, nil
-	case applyPlannedState.IsNull():
-		// Delete the resource
-		return resp, nil
 	}
+	s.logger.Trace("[ApplyResourceChange]", "[NewState]", dump(newState))
 
+	resp.NewState = &newState
 	return resp, nil
 }
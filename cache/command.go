@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+var commandCapturedAttributes = map[string]tftypes.Type{
+	"stdout":    tftypes.String,
+	"stderr":    tftypes.String,
+	"exit_code": tftypes.Number,
+	"timestamp": tftypes.String,
+}
+
+// commandExitCodes decodes 'allowed_exit_codes', the non-zero exit codes that are cached rather than failing the apply.
+func commandExitCodes(v tftypes.Value) []int64 {
+	if v.IsNull() || !v.IsFullyKnown() {
+		return nil
+	}
+	var vals []tftypes.Value
+	_ = v.As(&vals)
+	codes := make([]int64, 0, len(vals))
+	for _, c := range vals {
+		n := new(big.Float)
+		if c.IsNull() || c.As(&n) != nil || !n.IsInt() {
+			continue
+		}
+		code, _ := n.Int64()
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+func validateCommand(configVal map[string]tftypes.Value) (diags []*tfprotov5.Diagnostic) {
+	if v := configVal["command"]; v.IsKnown() {
+		// A null command is as empty as an empty list, and so is a null or empty program.
+		var argv []tftypes.Value
+		_ = v.As(&argv)
+		empty := len(argv) == 0
+		if !empty && argv[0].IsKnown() {
+			var program *string
+			_ = argv[0].As(&program)
+			empty = program == nil || *program == ""
+		}
+		if empty {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityError,
+				Summary:   "Empty command",
+				Detail:    "The command must contain at least the program to run.",
+				Attribute: tftypes.NewAttributePath().WithAttributeName("command"),
+			})
+		}
+	}
+	if v := configVal["allowed_exit_codes"]; v.IsFullyKnown() && !v.IsNull() {
+		var codes []tftypes.Value
+		_ = v.As(&codes)
+		for i, c := range codes {
+			n := new(big.Float)
+			if !c.IsNull() {
+				_ = c.As(&n)
+			}
+			if code, acc := n.Int64(); c.IsNull() || acc != big.Exact || code < 0 || code > 255 {
+				diags = append(diags, &tfprotov5.Diagnostic{
+					Severity:  tfprotov5.DiagnosticSeverityError,
+					Summary:   "Invalid exit code",
+					Detail:    "Exit codes must be whole numbers between 0 and 255.",
+					Attribute: tftypes.NewAttributePath().WithAttributeName("allowed_exit_codes").WithElementKeyInt(i),
+				})
+			}
+		}
+	}
+	if v := configVal["timeout"]; v.IsKnown() && !v.IsNull() {
+		var timeout string
+		_ = v.As(&timeout)
+		if d, err := time.ParseDuration(timeout); err != nil || d <= 0 {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityError,
+				Summary:   "Invalid timeout",
+				Detail:    fmt.Sprintf("%q is not a positive duration such as \"30s\" or \"5m\".", timeout),
+				Attribute: tftypes.NewAttributePath().WithAttributeName("timeout"),
+			})
+		}
+	}
+	return
+}
+
+// planCommand defers running the command to apply. Once it has run, the whole configuration is frozen like a cache_store value.
+func (s *RawProviderServer) planCommand(priorState tftypes.Value, priorVal, proposedVal map[string]tftypes.Value, resp *tfprotov5.PlanResourceChangeResponse) {
+	if priorState.IsNull() {
+		// plan for Create
+		for name, typ := range commandCapturedAttributes {
+			proposedVal[name] = tftypes.NewValue(typ, tftypes.UnknownValue)
+		}
+		return
+	}
+
+	// plan for Update
+	// The captured output is frozen. Changed arguments keep their prior value, but removed ones follow the configuration,
+	// since Terraform only accepts the prior value in place of a configured one.
+	for _, name := range []string{"command", "environment", "working_dir", "timeout", "allowed_exit_codes"} {
+		if !proposedVal[name].IsNull() && !priorVal[name].IsNull() {
+			proposedVal[name] = priorVal[name]
+		}
+	}
+}
+
+// applyCommand runs the command on create and captures its output.
+func (s *RawProviderServer) applyCommand(ctx context.Context, plannedVal map[string]tftypes.Value, resp *tfprotov5.ApplyResourceChangeResponse) {
+	if plannedVal["stdout"].IsKnown() {
+		return
+	}
+
+	var argvVals []tftypes.Value
+	_ = plannedVal["command"].As(&argvVals)
+	argv := make([]string, len(argvVals))
+	for i, a := range argvVals {
+		_ = a.As(&argv[i])
+	}
+	if len(argv) == 0 || argv[0] == "" {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity:  tfprotov5.DiagnosticSeverityError,
+			Summary:   "Empty command",
+			Detail:    "The command must contain at least the program to run.",
+			Attribute: tftypes.NewAttributePath().WithAttributeName("command"),
+		})
+		return
+	}
+	envVals := map[string]tftypes.Value{}
+	_ = plannedVal["environment"].As(&envVals)
+	var workingDir, timeout string
+	_ = plannedVal["working_dir"].As(&workingDir)
+	_ = plannedVal["timeout"].As(&timeout)
+
+	if timeout != "" {
+		d, _ := time.ParseDuration(timeout)
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = workingDir
+	cmd.Env = os.Environ()
+	for k, v := range envVals {
+		var val string
+		_ = v.As(&val)
+		cmd.Env = append(cmd.Env, k+"="+val)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	s.logger.Trace("[ApplyResourceChange]", "[Command]", dump(argv))
+	err := cmd.Run()
+	var exitCode int64
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && ctx.Err() == nil && exitErr.ExitCode() >= 0 {
+		exitCode = int64(exitErr.ExitCode())
+		for _, allowed := range commandExitCodes(plannedVal["allowed_exit_codes"]) {
+			if exitCode == allowed {
+				err = nil
+			}
+		}
+	}
+	if err != nil {
+		detail := fmt.Sprintf("Running %q failed: %s", strings.Join(argv, " "), err)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			detail = fmt.Sprintf("Running %q was cancelled because the provider was stopped.", strings.Join(argv, " "))
+		} else if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			detail = fmt.Sprintf("Running %q timed out after %s.", strings.Join(argv, " "), timeout)
+		} else if !errors.As(err, &exitErr) {
+			detail = fmt.Sprintf("Running %q failed to start: %s", strings.Join(argv, " "), err)
+		}
+		if stderr.Len() > 0 {
+			detail += "\n\nstderr:\n" + stderr.String()
+		}
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity:  tfprotov5.DiagnosticSeverityError,
+			Summary:   "Command failed",
+			Detail:    detail,
+			Attribute: tftypes.NewAttributePath().WithAttributeName("command"),
+		})
+		return
+	}
+
+	plannedVal["stdout"] = tftypes.NewValue(tftypes.String, stdout.String())
+	plannedVal["stderr"] = tftypes.NewValue(tftypes.String, stderr.String())
+	plannedVal["exit_code"] = tftypes.NewValue(tftypes.Number, exitCode)
+	plannedVal["timestamp"] = tftypes.NewValue(tftypes.String, fmt.Sprint(time.Now().Unix()))
+}
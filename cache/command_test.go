@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func commandList(argv ...string) tftypes.Value {
+	vals := make([]tftypes.Value, len(argv))
+	for i, a := range argv {
+		vals[i] = tftypes.NewValue(tftypes.String, a)
+	}
+	return tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, vals)
+}
+
+func TestHarnessCommandLifecycle(t *testing.T) {
+	h := newTestHarness(t, "cache_command")
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{
+		"command": commandList("echo", "first"),
+		"timeout": tftypes.NewValue(tftypes.String, "10s"),
+	}))
+	if !h.state["stdout"].Equal(tftypes.NewValue(tftypes.String, "first\n")) {
+		t.Fatalf("unexpected stdout %s", h.state["stdout"])
+	}
+
+	// A changed command does not run again, and removing an argument is a valid plan.
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{
+		"command": commandList("echo", "second"),
+	}))
+	if !h.state["stdout"].Equal(tftypes.NewValue(tftypes.String, "first\n")) {
+		t.Errorf("the command ran again, stdout is %s", h.state["stdout"])
+	}
+	if !h.state["timeout"].IsNull() {
+		t.Errorf("a removed timeout should be null, got %s", h.state["timeout"])
+	}
+	if !h.state["command"].Equal(commandList("echo", "first")) {
+		t.Errorf("the command should stay frozen, got %s", h.state["command"])
+	}
+	assertNoDiagnostics(t, h.destroy())
+}
+
+func TestHarnessCommandFailure(t *testing.T) {
+	h := newTestHarness(t, "cache_command")
+	diags := h.step(map[string]tftypes.Value{"command": commandList("false")})
+	assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityError, "Command failed")
+	if h.state != nil {
+		t.Error("a failed command should not be cached")
+	}
+}
+
+func TestValidateCommandEmpty(t *testing.T) {
+	h := newTestHarness(t, "cache_command")
+	for name, command := range map[string]tftypes.Value{
+		"null":          tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, nil),
+		"empty list":    commandList(),
+		"empty program": commandList("", "arg"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			assertDiagnostic(t, h.validate(map[string]tftypes.Value{"command": command}), tfprotov5.DiagnosticSeverityError, "Empty command")
+		})
+	}
+}
+
+func TestApplyCommandNull(t *testing.T) {
+	planned := map[string]tftypes.Value{}
+	for name, typ := range commandCapturedAttributes {
+		planned[name] = tftypes.NewValue(typ, tftypes.UnknownValue)
+	}
+	h := newTestHarness(t, "cache_command")
+	resp, err := h.s.ApplyResourceChange(context.Background(), &tfprotov5.ApplyResourceChangeRequest{
+		TypeName:     "cache_command",
+		PriorState:   encodeState(t, "cache_command", nil),
+		PlannedState: encodeState(t, "cache_command", planned),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDiagnostic(t, resp.Diagnostics, tfprotov5.DiagnosticSeverityError, "Empty command")
+}
+
+func TestHarnessCommandAllowedExitCode(t *testing.T) {
+	h := newTestHarness(t, "cache_command")
+	codes := tftypes.NewValue(tftypes.List{ElementType: tftypes.Number}, []tftypes.Value{tftypes.NewValue(tftypes.Number, 1)})
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{
+		"command":            commandList("sh", "-c", "echo partial; exit 1"),
+		"allowed_exit_codes": codes,
+	}))
+	if !h.state["exit_code"].Equal(tftypes.NewValue(tftypes.Number, 1)) {
+		t.Errorf("expected exit code 1, got %s", h.state["exit_code"])
+	}
+	if !h.state["stdout"].Equal(tftypes.NewValue(tftypes.String, "partial\n")) {
+		t.Errorf("unexpected stdout %s", h.state["stdout"])
+	}
+
+	h = newTestHarness(t, "cache_command")
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{"command": commandList("true"), "allowed_exit_codes": codes}))
+	if !h.state["exit_code"].Equal(tftypes.NewValue(tftypes.Number, 0)) {
+		t.Errorf("expected exit code 0, got %s", h.state["exit_code"])
+	}
+
+	h = newTestHarness(t, "cache_command")
+	diags := h.step(map[string]tftypes.Value{"command": commandList("sh", "-c", "exit 2"), "allowed_exit_codes": codes})
+	assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityError, "Command failed")
+}
+
+func TestValidateCommandExitCodes(t *testing.T) {
+	h := newTestHarness(t, "cache_command")
+	for _, code := range []float64{-1, 256, 1.5} {
+		codes := tftypes.NewValue(tftypes.List{ElementType: tftypes.Number}, []tftypes.Value{tftypes.NewValue(tftypes.Number, code)})
+		diags := h.validate(map[string]tftypes.Value{"command": commandList("true"), "allowed_exit_codes": codes})
+		assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityError, "Invalid exit code")
+	}
+}
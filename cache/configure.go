@@ -3,8 +3,10 @@ package cache
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"golang.org/x/mod/semver"
 )
 
@@ -12,7 +14,37 @@ const minTFVersion string = "v0.14.8"
 
 // ConfigureProvider function
 func (s *RawProviderServer) ConfigureProvider(ctx context.Context, req *tfprotov5.ConfigureProviderRequest) (*tfprotov5.ConfigureProviderResponse, error) {
-	return &tfprotov5.ConfigureProviderResponse{}, nil
+	resp := &tfprotov5.ConfigureProviderResponse{}
+
+	if req.TerraformVersion != "" {
+		s.hostTFVersion = "v" + strings.TrimPrefix(req.TerraformVersion, "v")
+	}
+
+	if req.Config == nil {
+		return resp, nil
+	}
+	cfgType := GetObjectTypeFromSchema(GetProviderConfigSchema())
+	cfgVal, err := req.Config.Unmarshal(cfgType)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  "Failed to decode provider configuration",
+			Detail:   err.Error(),
+		})
+		return resp, nil
+	}
+	cfg := make(map[string]tftypes.Value)
+	_ = cfgVal.As(&cfg)
+
+	var names []tftypes.Value
+	_ = cfg["captured_by_env"].As(&names)
+	s.capturedByEnv = nil
+	for _, n := range names {
+		var name string
+		_ = n.As(&name)
+		s.capturedByEnv = append(s.capturedByEnv, name)
+	}
+	return resp, nil
 }
 
 func (s *RawProviderServer) canExecute() (resp []*tfprotov5.Diagnostic) {
@@ -0,0 +1,207 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed five field cron expression (minute, hour, day of month, month, day of week), evaluated in UTC.
+// On top of the usual syntax, the day of week field accepts "DAY#n" for the n-th such weekday of the month,
+// so "0 6 * * MON#1" is six o'clock on the first Monday of every month.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	nth                           []nthWeekday
+	domAny, dowAny                bool
+}
+
+type nthWeekday struct {
+	weekday time.Weekday
+	n       int
+}
+
+type cronField struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronMinute = cronField{min: 0, max: 59}
+	cronHour   = cronField{min: 0, max: 23}
+	cronDom    = cronField{min: 1, max: 31}
+	cronMonth  = cronField{min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}}
+	cronDow = cronField{min: 0, max: 7, names: map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}}
+)
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// parseCron parses a cron expression or one of the @yearly, @monthly, @weekly, @daily and @hourly descriptors.
+func parseCron(expr string) (*cronSchedule, error) {
+	if d, ok := cronDescriptors[strings.TrimSpace(expr)]; ok {
+		expr = d
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields (minute hour day-of-month month day-of-week), got %d", len(fields))
+	}
+
+	c := &cronSchedule{}
+	var err error
+	if c.minute, err = cronMinute.parse(fields[0]); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if c.hour, err = cronHour.parse(fields[1]); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if c.dom, err = cronDom.parse(fields[2]); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if c.month, err = cronMonth.parse(fields[3]); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+
+	var plain []string
+	for _, part := range strings.Split(fields[4], ",") {
+		i := strings.Index(part, "#")
+		if i < 0 {
+			plain = append(plain, part)
+			continue
+		}
+		wd, err := cronDow.value(part[:i])
+		if err != nil {
+			return nil, fmt.Errorf("day of week: %w", err)
+		}
+		n, err := strconv.Atoi(part[i+1:])
+		if err != nil || n < 1 || n > 5 {
+			return nil, fmt.Errorf("day of week: invalid occurrence in %q", part)
+		}
+		c.nth = append(c.nth, nthWeekday{weekday: time.Weekday(wd % 7), n: n})
+	}
+	if len(plain) > 0 {
+		if c.dow, err = cronDow.parse(strings.Join(plain, ",")); err != nil {
+			return nil, fmt.Errorf("day of week: %w", err)
+		}
+		// Sunday can be written as 0 or 7
+		if c.dow&(1<<7) != 0 {
+			c.dow |= 1
+		}
+	}
+
+	c.domAny = fields[2] == "*" || fields[2] == "?"
+	c.dowAny = fields[4] == "*" || fields[4] == "?"
+	return c, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToUpper(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%q is not a value between %d and %d", s, f.min, f.max)
+	}
+	return v, nil
+}
+
+func (f cronField) parse(s string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:i]
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case part == "*" || part == "?":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			v, err := f.value(part)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if step == 1 {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	if c.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	for _, nth := range c.nth {
+		if t.Weekday() == nth.weekday && (t.Day()-1)/7+1 == nth.n {
+			dowMatch = true
+		}
+	}
+	// as in regular cron, a day matches either field when both are restricted
+	if c.domAny || c.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// next returns the first time strictly after t that matches the schedule.
+// The zero time is returned if nothing matches within the next five years.
+func (c *cronSchedule) next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	for limit := day.AddDate(5, 0, 0); day.Before(limit); day = day.AddDate(0, 0, 1) {
+		if !c.dayMatches(day) {
+			continue
+		}
+		for h := 0; h < 24; h++ {
+			if c.hour&(1<<uint(h)) == 0 {
+				continue
+			}
+			for m := 0; m < 60; m++ {
+				if c.minute&(1<<uint(m)) == 0 {
+					continue
+				}
+				if candidate := day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute); !candidate.Before(t) {
+					return candidate
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * * MON#0",
+		"* * * * MON#6",
+		"* * * * FOO#1",
+		"@fortnightly",
+	} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("expected an error for %q", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// 2024-01-01 is a Monday.
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"0 0 * * *", from, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", from.Add(30 * time.Second), time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)},
+		{"@monthly", from.AddDate(0, 0, 14), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+
+		// steps
+		{"*/15 * * * *", from, time.Date(2024, 1, 1, 0, 15, 0, 0, time.UTC)},
+		{"5/20 * * * *", from.Add(30 * time.Minute), time.Date(2024, 1, 1, 0, 45, 0, 0, time.UTC)},
+		{"0 0-23/6 * * *", from.Add(time.Hour), time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC)},
+
+		// the n-th weekday of the month
+		{"0 6 * * MON#1", from, time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC)},
+		{"0 6 * * MON#1", from.Add(7 * time.Hour), time.Date(2024, 2, 5, 6, 0, 0, 0, time.UTC)},
+		{"0 0 * * FRI#5", from, time.Date(2024, 3, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * TUE#2,THU#1", from, time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC)},
+
+		// a day matches either field when both are restricted, and both fields otherwise
+		{"0 0 13 * FRI", from, time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * *", from, time.Date(2024, 1, 13, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * FRI", from, time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * ?", from, time.Date(2024, 1, 13, 0, 0, 0, 0, time.UTC)},
+
+		// Sunday as 0 or 7
+		{"0 0 * * 0", from, time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", from, time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * SUN", from, time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 6-7", from, time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC)},
+
+		{"0 0 29 2 *", from, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+
+		// impossible dates never match
+		{"0 0 31 2 *", from, time.Time{}},
+		{"0 0 30 2 *", from, time.Time{}},
+		{"0 0 31 4,6,9,11 *", from, time.Time{}},
+	}
+	for _, c := range cases {
+		sched, err := parseCron(c.expr)
+		if err != nil {
+			t.Errorf("%q: %s", c.expr, err)
+			continue
+		}
+		if got := sched.next(c.from); !got.Equal(c.want) {
+			t.Errorf("%q after %s: expected %s, got %s", c.expr, c.from, c.want, got)
+		}
+	}
+}
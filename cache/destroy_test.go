@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestStorePreventDestroyWithin(t *testing.T) {
+	h := newTestHarness(t, "cache_store")
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.s.clock = func() time.Time { return created }
+	config := map[string]tftypes.Value{
+		"value":                  tftypes.NewValue(tftypes.String, "ami-1"),
+		"prevent_destroy_within": tftypes.NewValue(tftypes.String, "1h"),
+	}
+	assertNoDiagnostics(t, h.step(config))
+
+	diags := h.destroy()
+	assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityError, "protected from destroy")
+	if h.state == nil {
+		t.Fatal("a protected entry should not be destroyed")
+	}
+
+	// Once the protection has passed, the entry can be destroyed.
+	h.s.clock = func() time.Time { return created.Add(2 * time.Hour) }
+	assertNoDiagnostics(t, h.destroy())
+}
+
+func TestStorePreventDestroyWithinUnixTimestamp(t *testing.T) {
+	prior := map[string]tftypes.Value{
+		"value":                  tftypes.NewValue(tftypes.String, "ami-1"),
+		"timestamp":              tftypes.NewValue(tftypes.String, "1700000000"),
+		"prevent_destroy_within": tftypes.NewValue(tftypes.String, "24h"),
+	}
+	created := time.Unix(1700000000, 0)
+
+	resp := &tfprotov5.PlanResourceChangeResponse{}
+	planStoreDestroy(prior, created.Add(time.Hour), resp)
+	assertDiagnostic(t, resp.Diagnostics, tfprotov5.DiagnosticSeverityError, "protected from destroy")
+
+	resp = &tfprotov5.PlanResourceChangeResponse{}
+	planStoreDestroy(prior, created.Add(25*time.Hour), resp)
+	assertNoDiagnostics(t, resp.Diagnostics)
+}
+
+func TestStorePreventDestroyWithinPending(t *testing.T) {
+	h := newTestHarness(t, "cache_store")
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{
+		"value":                  tftypes.NewValue(tftypes.String, nil),
+		"capture_when":           tftypes.NewValue(tftypes.String, "known_and_not_null"),
+		"prevent_destroy_within": tftypes.NewValue(tftypes.String, "1h"),
+	}))
+	assertNoDiagnostics(t, h.destroy())
+}
+
+func TestStorePreventDestroyWithinInvalid(t *testing.T) {
+	h := newTestHarness(t, "cache_store")
+	for _, within := range []string{"soon", "-1h", "0s"} {
+		diags := h.validate(map[string]tftypes.Value{
+			"value":                  tftypes.NewValue(tftypes.String, "ami-1"),
+			"prevent_destroy_within": tftypes.NewValue(tftypes.String, within),
+		})
+		assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityError, "Invalid destroy protection")
+	}
+}
+
+func TestPlanDestroyCapability(t *testing.T) {
+	// Without PlanDestroy, Terraform never asks the provider to plan a destroy and the protection would not apply.
+	resp, err := newTestHarness(t, "cache_store").s.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ServerCapabilities == nil || !resp.ServerCapabilities.PlanDestroy {
+		t.Error("the provider must advertise the PlanDestroy capability")
+	}
+}
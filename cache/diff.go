@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// valueDifferences returns the paths, rooted at path, at which the known parts of a and b differ.
+// Unknown values never count as a difference, so partially-known configurations can still be compared.
+func valueDifferences(path *tftypes.AttributePath, a, b tftypes.Value) []*tftypes.AttributePath {
+	if !a.IsKnown() || !b.IsKnown() {
+		return nil
+	}
+	if a.IsNull() || b.IsNull() {
+		if a.IsNull() == b.IsNull() {
+			return nil
+		}
+		return []*tftypes.AttributePath{path}
+	}
+	if !a.Type().Equal(b.Type()) {
+		return []*tftypes.AttributePath{path}
+	}
+
+	switch {
+	case a.Type().Is(tftypes.Object{}), a.Type().Is(tftypes.Map{}):
+		am := map[string]tftypes.Value{}
+		bm := map[string]tftypes.Value{}
+		_ = a.As(&am)
+		_ = b.As(&bm)
+		keys := make([]string, 0, len(am))
+		for k := range am {
+			keys = append(keys, k)
+		}
+		for k := range bm {
+			if _, ok := am[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		var diffs []*tftypes.AttributePath
+		for _, k := range keys {
+			var p *tftypes.AttributePath
+			if a.Type().Is(tftypes.Object{}) {
+				p = path.WithAttributeName(k)
+			} else {
+				p = path.WithElementKeyString(k)
+			}
+			av, aok := am[k]
+			bv, bok := bm[k]
+			if !aok || !bok {
+				diffs = append(diffs, p)
+				continue
+			}
+			diffs = append(diffs, valueDifferences(p, av, bv)...)
+		}
+		return diffs
+	case a.Type().Is(tftypes.List{}), a.Type().Is(tftypes.Set{}), a.Type().Is(tftypes.Tuple{}):
+		var al, bl []tftypes.Value
+		_ = a.As(&al)
+		_ = b.As(&bl)
+		if len(al) != len(bl) {
+			return []*tftypes.AttributePath{path}
+		}
+		var diffs []*tftypes.AttributePath
+		for i := range al {
+			diffs = append(diffs, valueDifferences(path.WithElementKeyInt(i), al[i], bl[i])...)
+		}
+		return diffs
+	case a.Type().Is(tftypes.Number):
+		an, bn := big.NewFloat(0), big.NewFloat(0)
+		_ = a.As(an)
+		_ = b.As(bn)
+		if an.Cmp(bn) != 0 {
+			return []*tftypes.AttributePath{path}
+		}
+		return nil
+	default:
+		if !a.Equal(b) {
+			return []*tftypes.AttributePath{path}
+		}
+		return nil
+	}
+}
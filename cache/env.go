@@ -0,0 +1,173 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+type envVariable struct {
+	name      string
+	required  bool
+	sensitive bool
+	def       *string
+}
+
+// envVariables decodes the 'variable' blocks. It reports false while any of them is not known yet.
+func envVariables(blocks tftypes.Value) ([]envVariable, bool) {
+	if !blocks.IsFullyKnown() {
+		return nil, false
+	}
+	var list []tftypes.Value
+	_ = blocks.As(&list)
+
+	vars := make([]envVariable, 0, len(list))
+	for _, b := range list {
+		attrs := map[string]tftypes.Value{}
+		_ = b.As(&attrs)
+		var v envVariable
+		_ = attrs["name"].As(&v.name)
+		_ = attrs["required"].As(&v.required)
+		_ = attrs["sensitive"].As(&v.sensitive)
+		_ = attrs["default"].As(&v.def)
+		vars = append(vars, v)
+	}
+	return vars, true
+}
+
+func validateEnv(configVal map[string]tftypes.Value) (diags []*tfprotov5.Diagnostic) {
+	vars, ok := envVariables(configVal["variable"])
+	if !ok {
+		return
+	}
+	seen := map[string]bool{}
+	for i, v := range vars {
+		path := tftypes.NewAttributePath().WithAttributeName("variable").WithElementKeyInt(i)
+		if seen[v.name] {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityError,
+				Summary:   "Duplicate variable",
+				Detail:    fmt.Sprintf("The environment variable %q is declared more than once.", v.name),
+				Attribute: path.WithAttributeName("name"),
+			})
+		}
+		seen[v.name] = true
+		if v.required && v.def != nil {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityError,
+				Summary:   "Conflicting variable settings",
+				Detail:    fmt.Sprintf("The environment variable %q cannot be both required and have a default.", v.name),
+				Attribute: path.WithAttributeName("default"),
+			})
+		}
+	}
+	return
+}
+
+// planEnv defers reading the environment to apply. Variables that were captured before keep their value;
+// only variables added to the configuration later are captured on update.
+func (s *RawProviderServer) planEnv(priorState tftypes.Value, priorVal, proposedVal map[string]tftypes.Value, resp *tfprotov5.PlanResourceChangeResponse) {
+	unknownMap := tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, tftypes.UnknownValue)
+
+	if priorState.IsNull() {
+		// plan for Create
+		proposedVal["values"] = unknownMap
+		proposedVal["sensitive_values"] = unknownMap
+		proposedVal["timestamp"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+		return
+	}
+
+	// plan for Update
+	prior, _ := envVariables(priorVal["variable"])
+	proposed, ok := envVariables(proposedVal["variable"])
+	if ok && sameEnvCapture(prior, proposed) {
+		return
+	}
+	proposedVal["values"] = unknownMap
+	proposedVal["sensitive_values"] = unknownMap
+}
+
+// sameEnvCapture reports whether two sets of variables would capture the same names into the same maps.
+func sameEnvCapture(a, b []envVariable) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sensitive := map[string]bool{}
+	for _, v := range a {
+		sensitive[v.name] = v.sensitive
+	}
+	for _, v := range b {
+		s, ok := sensitive[v.name]
+		if !ok || s != v.sensitive {
+			return false
+		}
+	}
+	return true
+}
+
+// applyEnv snapshots the environment of the provider process, keeping any values captured previously.
+func (s *RawProviderServer) applyEnv(priorState tftypes.Value, plannedVal map[string]tftypes.Value, resp *tfprotov5.ApplyResourceChangeResponse) {
+	if plannedVal["values"].IsKnown() {
+		return
+	}
+
+	captured := map[string]string{}
+	if !priorState.IsNull() {
+		priorVal := map[string]tftypes.Value{}
+		_ = priorState.As(&priorVal)
+		prior, _ := envVariables(priorVal["variable"])
+		for _, name := range []string{"values", "sensitive_values"} {
+			m := map[string]tftypes.Value{}
+			_ = priorVal[name].As(&m)
+			for _, v := range prior {
+				if val, ok := m[v.name]; ok {
+					var str string
+					_ = val.As(&str)
+					captured[v.name] = str
+				}
+			}
+		}
+	}
+
+	vars, _ := envVariables(plannedVal["variable"])
+	values := map[string]tftypes.Value{}
+	sensitiveValues := map[string]tftypes.Value{}
+	for i, v := range vars {
+		val, ok := captured[v.name]
+		if !ok {
+			val, ok = os.LookupEnv(v.name)
+		}
+		if !ok && v.def != nil {
+			val, ok = *v.def, true
+		}
+		if !ok {
+			if v.required {
+				detail := fmt.Sprintf("The environment variable %q must be set when this resource is created.", v.name)
+				if !priorState.IsNull() {
+					detail = fmt.Sprintf("The environment variable %q is required but has not been captured yet, so it must be set when this resource is updated.", v.name)
+				}
+				resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+					Severity:  tfprotov5.DiagnosticSeverityError,
+					Summary:   "Required environment variable not set",
+					Detail:    detail,
+					Attribute: tftypes.NewAttributePath().WithAttributeName("variable").WithElementKeyInt(i),
+				})
+			}
+			continue
+		}
+		if v.sensitive {
+			sensitiveValues[v.name] = tftypes.NewValue(tftypes.String, val)
+		} else {
+			values[v.name] = tftypes.NewValue(tftypes.String, val)
+		}
+	}
+
+	plannedVal["values"] = tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, values)
+	plannedVal["sensitive_values"] = tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, sensitiveValues)
+	if !plannedVal["timestamp"].IsKnown() {
+		plannedVal["timestamp"] = tftypes.NewValue(tftypes.String, fmt.Sprint(time.Now().Unix()))
+	}
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+const (
+	fileEncodingText   = "text"
+	fileEncodingBase64 = "base64"
+)
+
+var fileCapturedAttributes = map[string]tftypes.Type{
+	"content":   tftypes.String,
+	"size":      tftypes.Number,
+	"mode":      tftypes.String,
+	"sha256":    tftypes.String,
+	"timestamp": tftypes.String,
+}
+
+func validateFile(configVal map[string]tftypes.Value) (diags []*tfprotov5.Diagnostic) {
+	if v := configVal["encoding"]; v.IsKnown() && !v.IsNull() {
+		var encoding string
+		_ = v.As(&encoding)
+		if encoding != fileEncodingText && encoding != fileEncodingBase64 {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityError,
+				Summary:   "Invalid encoding",
+				Detail:    fmt.Sprintf("Encoding must be %q or %q, got %q.", fileEncodingText, fileEncodingBase64, encoding),
+				Attribute: tftypes.NewAttributePath().WithAttributeName("encoding"),
+			})
+		}
+	}
+	return
+}
+
+// planFile defers reading the file to apply, since it may only be generated during the same run.
+// Once captured, 'path' and 'encoding' are frozen like a cache_store value; only 'triggers' re-capture the file.
+// 'encoding' is computed so that it keeps describing 'content' when it is removed from the configuration.
+func (s *RawProviderServer) planFile(priorState tftypes.Value, priorVal, proposedVal map[string]tftypes.Value, resp *tfprotov5.PlanResourceChangeResponse) {
+	if priorState.IsNull() {
+		// plan for Create
+		for name, typ := range fileCapturedAttributes {
+			proposedVal[name] = tftypes.NewValue(typ, tftypes.UnknownValue)
+		}
+		if proposedVal["encoding"].IsNull() {
+			proposedVal["encoding"] = tftypes.NewValue(tftypes.String, fileEncodingText)
+		}
+		return
+	}
+
+	// plan for Update
+	if !proposedVal["triggers"].Equal(priorVal["triggers"]) {
+		resp.RequiresReplace = append(resp.RequiresReplace, tftypes.NewAttributePath().WithAttributeName("triggers"))
+		return
+	}
+	// States from before 'encoding' was computed may not record it; a configured encoding is then taken as is.
+	proposedVal["path"] = priorVal["path"]
+	if !priorVal["encoding"].IsNull() {
+		proposedVal["encoding"] = priorVal["encoding"]
+	}
+}
+
+// applyFile snapshots the file on create.
+func (s *RawProviderServer) applyFile(ctx context.Context, plannedVal map[string]tftypes.Value, resp *tfprotov5.ApplyResourceChangeResponse) {
+	if plannedVal["content"].IsKnown() || ctx.Err() != nil {
+		return
+	}
+
+	var path, encoding string
+	_ = plannedVal["path"].As(&path)
+	_ = plannedVal["encoding"].As(&encoding)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity:  tfprotov5.DiagnosticSeverityError,
+			Summary:   "Failed to read file",
+			Detail:    err.Error(),
+			Attribute: tftypes.NewAttributePath().WithAttributeName("path"),
+		})
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity:  tfprotov5.DiagnosticSeverityError,
+			Summary:   "Failed to read file",
+			Detail:    err.Error(),
+			Attribute: tftypes.NewAttributePath().WithAttributeName("path"),
+		})
+		return
+	}
+
+	content := string(data)
+	if encoding == fileEncodingBase64 {
+		content = base64.StdEncoding.EncodeToString(data)
+	} else if !utf8.Valid(data) {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity:  tfprotov5.DiagnosticSeverityError,
+			Summary:   "File is not valid UTF-8",
+			Detail:    fmt.Sprintf("%s cannot be stored as text. Set encoding = %q to capture binary files.", path, fileEncodingBase64),
+			Attribute: tftypes.NewAttributePath().WithAttributeName("encoding"),
+		})
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	plannedVal["content"] = tftypes.NewValue(tftypes.String, content)
+	plannedVal["size"] = tftypes.NewValue(tftypes.Number, len(data))
+	plannedVal["mode"] = tftypes.NewValue(tftypes.String, fmt.Sprintf("%04o", info.Mode().Perm()))
+	plannedVal["sha256"] = tftypes.NewValue(tftypes.String, hex.EncodeToString(sum[:]))
+	plannedVal["timestamp"] = tftypes.NewValue(tftypes.String, fmt.Sprint(time.Now().Unix()))
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func writeTestFile(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "snapshot")
+	if err := os.WriteFile(path, data, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestHarnessFileLifecycle(t *testing.T) {
+	path := writeTestFile(t, []byte("first"))
+	h := newTestHarness(t, "cache_file")
+	config := map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, path)}
+
+	assertNoDiagnostics(t, h.step(config))
+	expected := map[string]tftypes.Value{
+		"content":  tftypes.NewValue(tftypes.String, "first"),
+		"size":     tftypes.NewValue(tftypes.Number, 5),
+		"mode":     tftypes.NewValue(tftypes.String, "0640"),
+		"encoding": tftypes.NewValue(tftypes.String, fileEncodingText),
+	}
+	for name, v := range expected {
+		if !h.state[name].Equal(v) {
+			t.Errorf("expected %s = %s, got %s", name, v, h.state[name])
+		}
+	}
+
+	// Later changes to the file are ignored.
+	if err := os.WriteFile(path, []byte("second"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	assertNoDiagnostics(t, h.step(config))
+	if !h.state["content"].Equal(expected["content"]) {
+		t.Errorf("the file was captured again: %s", h.state["content"])
+	}
+	assertNoDiagnostics(t, h.destroy())
+}
+
+func TestHarnessFileRemovedEncoding(t *testing.T) {
+	data := []byte{0xff, 0xfe, 0x00}
+	path := writeTestFile(t, data)
+	h := newTestHarness(t, "cache_file")
+
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{
+		"path":     tftypes.NewValue(tftypes.String, path),
+		"encoding": tftypes.NewValue(tftypes.String, fileEncodingBase64),
+	}))
+	content := tftypes.NewValue(tftypes.String, base64.StdEncoding.EncodeToString(data))
+	if !h.state["content"].Equal(content) {
+		t.Fatalf("expected base64 content %s, got %s", content, h.state["content"])
+	}
+
+	// Removing the encoding keeps describing the captured content.
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, path)}))
+	if !h.state["encoding"].Equal(tftypes.NewValue(tftypes.String, fileEncodingBase64)) {
+		t.Errorf("encoding should stay base64, got %s", h.state["encoding"])
+	}
+	if !h.state["content"].Equal(content) {
+		t.Errorf("the content changed to %s", h.state["content"])
+	}
+}
+
+func TestHarnessFileMissing(t *testing.T) {
+	h := newTestHarness(t, "cache_file")
+	diags := h.step(map[string]tftypes.Value{
+		"path": tftypes.NewValue(tftypes.String, filepath.Join(t.TempDir(), "missing")),
+	})
+	assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityError, "Failed to read file")
+	if h.state != nil {
+		t.Error("a missing file should not be captured")
+	}
+}
+
+func TestHarnessFileNotUTF8(t *testing.T) {
+	h := newTestHarness(t, "cache_file")
+	diags := h.step(map[string]tftypes.Value{
+		"path": tftypes.NewValue(tftypes.String, writeTestFile(t, []byte{0xff, 0xfe})),
+	})
+	assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityError, "not valid UTF-8")
+}
+
+func TestValidateFileEncoding(t *testing.T) {
+	h := newTestHarness(t, "cache_file")
+	diags := h.validate(map[string]tftypes.Value{
+		"path":     tftypes.NewValue(tftypes.String, "f"),
+		"encoding": tftypes.NewValue(tftypes.String, "hex"),
+	})
+	assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityError, "Invalid encoding")
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// valueFingerprint returns a SHA-256 over a canonical encoding of a fully known value, including its type.
+// Equal values always produce the same fingerprint, regardless of map or object key order.
+func valueFingerprint(v tftypes.Value) string {
+	var sb strings.Builder
+	if tj, err := v.Type().MarshalJSON(); err == nil {
+		sb.Write(tj)
+	}
+	sb.WriteByte(':')
+	writeCanonical(&sb, v)
+	sum := sha256.Sum256([]byte(sb.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// fingerprintOrUnknown returns the fingerprint of v as a value, or an unknown value if v is not fully known yet.
+func fingerprintOrUnknown(v tftypes.Value) tftypes.Value {
+	if !v.IsFullyKnown() {
+		return tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+	}
+	return tftypes.NewValue(tftypes.String, valueFingerprint(v))
+}
+
+func writeCanonical(sb *strings.Builder, v tftypes.Value) {
+	if v.IsNull() {
+		sb.WriteString("null")
+		return
+	}
+	switch {
+	case v.Type().Is(tftypes.Object{}), v.Type().Is(tftypes.Map{}):
+		m := map[string]tftypes.Value{}
+		_ = v.As(&m)
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sb.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(strconv.Quote(k))
+			sb.WriteByte(':')
+			writeCanonical(sb, m[k])
+		}
+		sb.WriteByte('}')
+	case v.Type().Is(tftypes.List{}), v.Type().Is(tftypes.Set{}), v.Type().Is(tftypes.Tuple{}):
+		var l []tftypes.Value
+		_ = v.As(&l)
+		elems := make([]string, len(l))
+		for i, e := range l {
+			var esb strings.Builder
+			writeCanonical(&esb, e)
+			elems[i] = esb.String()
+		}
+		if v.Type().Is(tftypes.Set{}) {
+			sort.Strings(elems)
+		}
+		sb.WriteByte('[')
+		sb.WriteString(strings.Join(elems, ","))
+		sb.WriteByte(']')
+	case v.Type().Is(tftypes.Number):
+		n := big.NewFloat(0)
+		_ = v.As(n)
+		sb.WriteString(n.Text('g', -1))
+	case v.Type().Is(tftypes.Bool):
+		var b bool
+		_ = v.As(&b)
+		sb.WriteString(strconv.FormatBool(b))
+	default:
+		var s string
+		_ = v.As(&s)
+		sb.WriteString(strconv.Quote(s))
+	}
+}
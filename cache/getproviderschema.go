@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"log"
+	"sort"
 
 	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
 )
@@ -17,7 +18,35 @@ func (s *RawProviderServer) GetProviderSchema(ctx context.Context, req *tfprotov
 	log.Println("--------------------------GetProviderSchema Called------------------------------")
 
 	return &tfprotov5.GetProviderSchemaResponse{
-		Provider:        cfgSchema,
-		ResourceSchemas: resSchema,
+		ServerCapabilities: serverCapabilities(),
+		Provider:           cfgSchema,
+		ResourceSchemas:    resSchema,
 	}, nil
 }
+
+// GetMetadata function
+func (s *RawProviderServer) GetMetadata(ctx context.Context, req *tfprotov5.GetMetadataRequest) (*tfprotov5.GetMetadataResponse, error) {
+	resp := &tfprotov5.GetMetadataResponse{
+		ServerCapabilities: serverCapabilities(),
+	}
+	for name := range GetProviderResourceSchema() {
+		resp.Resources = append(resp.Resources, tfprotov5.ResourceMetadata{TypeName: name})
+	}
+	sort.Slice(resp.Resources, func(i, j int) bool { return resp.Resources[i].TypeName < resp.Resources[j].TypeName })
+	return resp, nil
+}
+
+// GetResourceIdentitySchemas function
+func (s *RawProviderServer) GetResourceIdentitySchemas(ctx context.Context, req *tfprotov5.GetResourceIdentitySchemasRequest) (*tfprotov5.GetResourceIdentitySchemasResponse, error) {
+	return &tfprotov5.GetResourceIdentitySchemasResponse{
+		IdentitySchemas: map[string]*tfprotov5.ResourceIdentitySchema{},
+	}, nil
+}
+
+func serverCapabilities() *tfprotov5.ServerCapabilities {
+	return &tfprotov5.ServerCapabilities{
+		GetProviderSchemaOptional: false,
+		MoveResourceState:         true,
+		PlanDestroy:               true,
+	}
+}
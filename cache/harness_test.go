@@ -0,0 +1,643 @@
+package cache
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// testHarness drives a single resource through the provider the way Terraform does, checking each response
+// against the rules Terraform applies to it. It keeps the state between steps.
+type testHarness struct {
+	t        *testing.T
+	s        *RawProviderServer
+	typeName string
+	schema   *tfprotov5.Schema
+	state    map[string]tftypes.Value
+}
+
+func newTestHarness(t *testing.T, typeName string) *testHarness {
+	t.Helper()
+	sch, ok := GetProviderResourceSchema()[typeName]
+	if !ok {
+		t.Fatalf("no schema for %s", typeName)
+	}
+	return &testHarness{
+		t:        t,
+		s:        newRawProviderServer(hclog.NewNullLogger()),
+		typeName: typeName,
+		schema:   sch,
+	}
+}
+
+// config completes a configuration, setting every attribute that is not given to null.
+func (h *testHarness) config(vals map[string]tftypes.Value) map[string]tftypes.Value {
+	h.t.Helper()
+	return decodeState(h.t, h.typeName, encodeState(h.t, h.typeName, vals))
+}
+
+func (h *testHarness) validate(config map[string]tftypes.Value) []*tfprotov5.Diagnostic {
+	h.t.Helper()
+	resp, err := h.s.ValidateResourceTypeConfig(context.Background(), &tfprotov5.ValidateResourceTypeConfigRequest{
+		TypeName: h.typeName,
+		Config:   encodeState(h.t, h.typeName, config),
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return resp.Diagnostics
+}
+
+// proposedNewState merges the configuration with the prior state as Terraform does:
+// computed attributes that are not configured keep their prior value.
+func (h *testHarness) proposedNewState(config map[string]tftypes.Value) map[string]tftypes.Value {
+	proposed := map[string]tftypes.Value{}
+	for k, v := range config {
+		proposed[k] = v
+	}
+	for _, a := range h.schema.Block.Attributes {
+		if a.Computed && config[a.Name].IsNull() && h.state != nil {
+			proposed[a.Name] = h.state[a.Name]
+		}
+	}
+	return proposed
+}
+
+// plan plans a change from the current state to config, failing the test if the plan is not one Terraform would accept.
+func (h *testHarness) plan(config map[string]tftypes.Value) (map[string]tftypes.Value, []*tfprotov5.Diagnostic) {
+	h.t.Helper()
+	config = h.config(config)
+	resp, err := h.s.PlanResourceChange(context.Background(), &tfprotov5.PlanResourceChangeRequest{
+		TypeName:         h.typeName,
+		PriorState:       encodeState(h.t, h.typeName, h.state),
+		ProposedNewState: encodeState(h.t, h.typeName, h.proposedNewState(config)),
+		Config:           encodeState(h.t, h.typeName, config),
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	if hasErrors(resp.Diagnostics) {
+		return nil, resp.Diagnostics
+	}
+	planned := decodeState(h.t, h.typeName, resp.PlannedState)
+	h.assertPlanValid(config, planned)
+	return planned, resp.Diagnostics
+}
+
+// assertPlanValid checks that only computed attributes were changed from the configuration,
+// unless the prior value was kept in place of an equivalent configured one.
+func (h *testHarness) assertPlanValid(config, planned map[string]tftypes.Value) {
+	h.t.Helper()
+	check := func(name string, computed bool) {
+		cv, pv := config[name], planned[name]
+		if pv.Equal(cv) {
+			return
+		}
+		if h.state != nil && pv.Equal(h.state[name]) && !pv.IsNull() && !cv.IsNull() {
+			return
+		}
+		if computed && cv.IsNull() {
+			return
+		}
+		h.t.Errorf("planned value %s for %q does not match the configured value %s", pv, name, cv)
+	}
+	for _, a := range h.schema.Block.Attributes {
+		check(a.Name, a.Computed)
+	}
+	for _, b := range h.schema.Block.BlockTypes {
+		check(b.TypeName, false)
+	}
+}
+
+// apply applies a planned change, failing the test if the new state does not conform to the plan.
+func (h *testHarness) apply(config, planned map[string]tftypes.Value) []*tfprotov5.Diagnostic {
+	h.t.Helper()
+	if config != nil {
+		config = h.config(config)
+	}
+	resp, err := h.s.ApplyResourceChange(context.Background(), &tfprotov5.ApplyResourceChangeRequest{
+		TypeName:     h.typeName,
+		PriorState:   encodeState(h.t, h.typeName, h.state),
+		PlannedState: encodeState(h.t, h.typeName, planned),
+		Config:       encodeState(h.t, h.typeName, config),
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	if hasErrors(resp.Diagnostics) {
+		return resp.Diagnostics
+	}
+	rt, _ := GetResourceType(h.typeName)
+	newState, err := resp.NewState.Unmarshal(rt)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	if newState.IsNull() {
+		if planned != nil {
+			h.t.Fatal("apply returned a null state for a planned update")
+		}
+		h.state = nil
+		return resp.Diagnostics
+	}
+	if planned == nil {
+		h.t.Fatal("apply returned a state for a planned destroy")
+	}
+	if !newState.IsFullyKnown() {
+		h.t.Errorf("apply returned a state with unknown values: %s", newState)
+	}
+	state := decodeState(h.t, h.typeName, resp.NewState)
+	for name, pv := range planned {
+		if d := valueDifferences(tftypes.NewAttributePath().WithAttributeName(name), pv, state[name]); len(d) > 0 {
+			h.t.Errorf("apply changed %q from the planned %s to %s", name, pv, state[name])
+		}
+	}
+	h.state = state
+	return resp.Diagnostics
+}
+
+// read refreshes the current state, which must not change it.
+func (h *testHarness) read() []*tfprotov5.Diagnostic {
+	h.t.Helper()
+	resp, err := h.s.ReadResource(context.Background(), &tfprotov5.ReadResourceRequest{
+		TypeName:     h.typeName,
+		CurrentState: encodeState(h.t, h.typeName, h.state),
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	if hasErrors(resp.Diagnostics) {
+		return resp.Diagnostics
+	}
+	state := decodeState(h.t, h.typeName, resp.NewState)
+	for name, v := range h.state {
+		if !state[name].Equal(v) {
+			h.t.Errorf("read changed %q from %s to %s", name, v, state[name])
+		}
+	}
+	h.state = state
+	return resp.Diagnostics
+}
+
+// destroy plans and applies the destroy of the current state, returning the diagnostics of the first RPC that fails.
+func (h *testHarness) destroy() []*tfprotov5.Diagnostic {
+	h.t.Helper()
+	resp, err := h.s.PlanResourceChange(context.Background(), &tfprotov5.PlanResourceChangeRequest{
+		TypeName:         h.typeName,
+		PriorState:       encodeState(h.t, h.typeName, h.state),
+		ProposedNewState: encodeState(h.t, h.typeName, nil),
+		Config:           encodeState(h.t, h.typeName, nil),
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	if hasErrors(resp.Diagnostics) {
+		return resp.Diagnostics
+	}
+	rt, _ := GetResourceType(h.typeName)
+	if planned, err := resp.PlannedState.Unmarshal(rt); err != nil || !planned.IsNull() {
+		h.t.Fatalf("a destroy should plan a null state, got %s (%v)", planned, err)
+	}
+	return append(resp.Diagnostics, h.apply(nil, nil)...)
+}
+
+// step runs the RPCs of a 'terraform apply' for config, then checks that planning again yields no changes.
+// It returns the diagnostics of every RPC, stopping at the first one that fails.
+func (h *testHarness) step(config map[string]tftypes.Value) []*tfprotov5.Diagnostic {
+	h.t.Helper()
+	var diags []*tfprotov5.Diagnostic
+	diags = append(diags, h.validate(config)...)
+	if hasErrors(diags) {
+		return diags
+	}
+	planned, d := h.plan(config)
+	if diags = append(diags, d...); hasErrors(diags) {
+		return diags
+	}
+	if diags = append(diags, h.apply(config, planned)...); hasErrors(diags) {
+		return diags
+	}
+	if diags = append(diags, h.read()...); hasErrors(diags) {
+		return diags
+	}
+	h.assertNoChanges(config)
+	return diags
+}
+
+// assertNoChanges checks that config plans no changes to the current state.
+func (h *testHarness) assertNoChanges(config map[string]tftypes.Value) {
+	h.t.Helper()
+	planned, diags := h.plan(config)
+	if hasErrors(diags) {
+		h.t.Fatalf("planning again failed: %s", diagnosticSummaries(diags))
+	}
+	for name, v := range h.state {
+		if !planned[name].Equal(v) {
+			h.t.Errorf("planning again changes %q from %s to %s", name, v, planned[name])
+		}
+	}
+}
+
+func diagnosticSummaries(diags []*tfprotov5.Diagnostic) string {
+	s := make([]string, 0, len(diags))
+	for _, d := range diags {
+		s = append(s, d.Summary+": "+d.Detail)
+	}
+	return strings.Join(s, "; ")
+}
+
+func assertNoDiagnostics(t *testing.T, diags []*tfprotov5.Diagnostic) {
+	t.Helper()
+	if len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics: %s", diagnosticSummaries(diags))
+	}
+}
+
+// assertDiagnostic checks that diags contain a diagnostic of the given severity whose summary contains summary.
+func assertDiagnostic(t *testing.T, diags []*tfprotov5.Diagnostic, severity tfprotov5.DiagnosticSeverity, summary string) {
+	t.Helper()
+	for _, d := range diags {
+		if d.Severity == severity && strings.Contains(d.Summary, summary) {
+			return
+		}
+	}
+	t.Fatalf("expected a diagnostic %q, got: %s", summary, diagnosticSummaries(diags))
+}
+
+func TestHarnessStoreLifecycle(t *testing.T) {
+	str := func(s string) tftypes.Value { return tftypes.NewValue(tftypes.String, s) }
+	num := func(n int) tftypes.Value { return tftypes.NewValue(tftypes.Number, n) }
+	strList := tftypes.List{ElementType: tftypes.String}
+	strSet := tftypes.Set{ElementType: tftypes.String}
+	numMap := tftypes.Map{ElementType: tftypes.Number}
+	pair := tftypes.Tuple{ElementTypes: []tftypes.Type{tftypes.String, tftypes.Number}}
+
+	cases := []struct {
+		name          string
+		first, second tftypes.Value
+	}{
+		{"string", str("ami-1"), str("ami-2")},
+		{"number", num(1), num(2)},
+		{"bool", tftypes.NewValue(tftypes.Bool, true), tftypes.NewValue(tftypes.Bool, false)},
+		{"list", tftypes.NewValue(strList, []tftypes.Value{str("a")}), tftypes.NewValue(strList, []tftypes.Value{str("a"), str("b")})},
+		{"set", tftypes.NewValue(strSet, []tftypes.Value{str("a")}), tftypes.NewValue(strSet, []tftypes.Value{str("b")})},
+		{"map", tftypes.NewValue(numMap, map[string]tftypes.Value{"a": num(1)}), tftypes.NewValue(numMap, map[string]tftypes.Value{"a": num(2)})},
+		{
+			"object",
+			tftypes.NewValue(testObjectType, map[string]tftypes.Value{"image_id": str("ami-1"), "count": num(1)}),
+			tftypes.NewValue(testObjectType, map[string]tftypes.Value{"image_id": str("ami-2"), "count": num(1)}),
+		},
+		{"tuple", tftypes.NewValue(pair, []tftypes.Value{str("a"), num(1)}), tftypes.NewValue(pair, []tftypes.Value{str("a"), num(2)})},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := newTestHarness(t, "cache_store")
+			first := map[string]tftypes.Value{"value": c.first}
+
+			assertNoDiagnostics(t, h.step(first))
+			if !h.state["value"].Equal(c.first) {
+				t.Fatalf("captured %s, expected %s", h.state["value"], c.first)
+			}
+
+			// Applying the same configuration again is a no-op.
+			timestamp := h.state["timestamp"]
+			assertNoDiagnostics(t, h.step(first))
+			if !h.state["timestamp"].Equal(timestamp) {
+				t.Error("a no-op apply should not capture again")
+			}
+
+			// A changed value is reported but the captured value is kept.
+			diags := h.step(map[string]tftypes.Value{"value": c.second})
+			assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityWarning, "")
+			if !h.state["value"].Equal(c.first) {
+				t.Errorf("the captured value changed to %s", h.state["value"])
+			}
+
+			assertNoDiagnostics(t, h.destroy())
+			if h.state != nil {
+				t.Error("destroy should remove the state")
+			}
+		})
+	}
+}
+
+func TestHarnessStoreUnknownValue(t *testing.T) {
+	h := newTestHarness(t, "cache_store")
+	unknown := map[string]tftypes.Value{"value": tftypes.NewValue(tftypes.DynamicPseudoType, tftypes.UnknownValue)}
+	known := map[string]tftypes.Value{"value": tftypes.NewValue(tftypes.String, "ami-1")}
+
+	assertNoDiagnostics(t, h.validate(unknown))
+	planned, diags := h.plan(unknown)
+	assertNoDiagnostics(t, diags)
+	for _, name := range []string{"value", "timestamp", "fingerprint"} {
+		if planned[name].IsKnown() {
+			t.Errorf("%q should be unknown until the value is known, got %s", name, planned[name])
+		}
+	}
+	assertNoDiagnostics(t, h.apply(known, planned))
+	if !h.state["value"].Equal(known["value"]) {
+		t.Errorf("captured %s, expected %s", h.state["value"], known["value"])
+	}
+	assertNoDiagnostics(t, h.read())
+	h.assertNoChanges(known)
+}
+
+func TestHarnessStorePendingValue(t *testing.T) {
+	h := newTestHarness(t, "cache_store")
+	when := tftypes.NewValue(tftypes.String, "known_and_not_null")
+	pending := map[string]tftypes.Value{"value": tftypes.NewValue(tftypes.String, nil), "capture_when": when}
+	known := map[string]tftypes.Value{"value": tftypes.NewValue(tftypes.String, "ami-1"), "capture_when": when}
+
+	assertNoDiagnostics(t, h.step(pending))
+	if !h.state["captured"].Equal(tftypes.NewValue(tftypes.Bool, false)) {
+		t.Fatalf("a null value should not be captured, got captured = %s", h.state["captured"])
+	}
+	assertNoDiagnostics(t, h.step(known))
+	if !h.state["captured"].Equal(tftypes.NewValue(tftypes.Bool, true)) || !h.state["value"].Equal(known["value"]) {
+		t.Errorf("the value should be captured once it is known, got %s", h.state["value"])
+	}
+}
+
+func TestHarnessInvalidConfig(t *testing.T) {
+	h := newTestHarness(t, "cache_store")
+	diags := h.step(map[string]tftypes.Value{
+		"value":        tftypes.NewValue(tftypes.String, "ami-1"),
+		"capture_when": tftypes.NewValue(tftypes.String, "sometimes"),
+	})
+	assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityError, "")
+	if h.state != nil {
+		t.Error("an invalid configuration should not be applied")
+	}
+}
+
+func TestHarnessVersionPinLifecycle(t *testing.T) {
+	h := newTestHarness(t, "cache_version_pin")
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{
+		"version": tftypes.NewValue(tftypes.String, "1.2.3"),
+		"policy":  tftypes.NewValue(tftypes.String, pinPolicyPatch),
+	}))
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{
+		"version": tftypes.NewValue(tftypes.String, "1.2.4"),
+		"policy":  tftypes.NewValue(tftypes.String, pinPolicyPatch),
+	}))
+	if !h.state["pinned_version"].Equal(tftypes.NewValue(tftypes.String, "1.2.4")) {
+		t.Errorf("a patch upgrade should move the pin, got %s", h.state["pinned_version"])
+	}
+}
+
+func TestHarnessVersionPinDestroy(t *testing.T) {
+	h := newTestHarness(t, "cache_version_pin")
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{"version": tftypes.NewValue(tftypes.String, "1.2.3")}))
+	assertNoDiagnostics(t, h.destroy())
+}
+
+// envConfig builds a cache_env configuration with a 'variable' block for each set of attributes.
+func envConfig(vars ...map[string]tftypes.Value) map[string]tftypes.Value {
+	rt, _ := GetResourceType("cache_env")
+	blockType := rt.(tftypes.Object).AttributeTypes["variable"].(tftypes.List)
+	elemType := blockType.ElementType.(tftypes.Object)
+	blocks := make([]tftypes.Value, 0, len(vars))
+	for _, v := range vars {
+		attrs := map[string]tftypes.Value{}
+		for name, typ := range elemType.AttributeTypes {
+			attrs[name] = tftypes.NewValue(typ, nil)
+		}
+		for name, val := range v {
+			attrs[name] = val
+		}
+		blocks = append(blocks, tftypes.NewValue(elemType, attrs))
+	}
+	return map[string]tftypes.Value{"variable": tftypes.NewValue(blockType, blocks)}
+}
+
+func envName(name string) map[string]tftypes.Value {
+	return map[string]tftypes.Value{"name": tftypes.NewValue(tftypes.String, name)}
+}
+
+func stringMap(vals map[string]string) tftypes.Value {
+	m := map[string]tftypes.Value{}
+	for k, v := range vals {
+		m[k] = tftypes.NewValue(tftypes.String, v)
+	}
+	return tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, m)
+}
+
+func TestHarnessEnvLifecycle(t *testing.T) {
+	t.Setenv("CACHE_HARNESS_REGION", "eu-west-1")
+	t.Setenv("CACHE_HARNESS_TOKEN", "secret")
+	t.Setenv("CACHE_HARNESS_DEFAULTED", "")
+	os.Unsetenv("CACHE_HARNESS_DEFAULTED")
+	t.Setenv("CACHE_HARNESS_UNSET", "")
+	os.Unsetenv("CACHE_HARNESS_UNSET")
+
+	region := envName("CACHE_HARNESS_REGION")
+	token := envName("CACHE_HARNESS_TOKEN")
+	token["sensitive"] = tftypes.NewValue(tftypes.Bool, true)
+	defaulted := envName("CACHE_HARNESS_DEFAULTED")
+	defaulted["default"] = tftypes.NewValue(tftypes.String, "fallback")
+
+	assertEnv := func(h *testHarness, values, sensitive map[string]string) {
+		t.Helper()
+		if !h.state["values"].Equal(stringMap(values)) {
+			t.Errorf("expected values %v, got %s", values, h.state["values"])
+		}
+		if !h.state["sensitive_values"].Equal(stringMap(sensitive)) {
+			t.Errorf("expected sensitive values %v, got %s", sensitive, h.state["sensitive_values"])
+		}
+	}
+
+	h := newTestHarness(t, "cache_env")
+	config := envConfig(region, token, defaulted)
+	assertNoDiagnostics(t, h.step(config))
+	values := map[string]string{"CACHE_HARNESS_REGION": "eu-west-1", "CACHE_HARNESS_DEFAULTED": "fallback"}
+	sensitive := map[string]string{"CACHE_HARNESS_TOKEN": "secret"}
+	assertEnv(h, values, sensitive)
+
+	// Later runs with a different environment keep the captured values.
+	t.Setenv("CACHE_HARNESS_REGION", "us-east-1")
+	t.Setenv("CACHE_HARNESS_TOKEN", "rotated")
+	t.Setenv("CACHE_HARNESS_DEFAULTED", "set")
+	h.assertNoChanges(config)
+	assertNoDiagnostics(t, h.step(config))
+	assertEnv(h, values, sensitive)
+
+	// A variable added later is captured from the environment of that run, the others are kept.
+	t.Setenv("CACHE_HARNESS_ZONE", "us-east-1a")
+	config = envConfig(region, token, defaulted, envName("CACHE_HARNESS_ZONE"))
+	assertNoDiagnostics(t, h.step(config))
+	values["CACHE_HARNESS_ZONE"] = "us-east-1a"
+	assertEnv(h, values, sensitive)
+
+	// A required variable added later must be set when the entry is updated.
+	required := envName("CACHE_HARNESS_UNSET")
+	required["required"] = tftypes.NewValue(tftypes.Bool, true)
+	diags := h.step(envConfig(region, token, defaulted, envName("CACHE_HARNESS_ZONE"), required))
+	assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityError, "Required environment variable not set")
+	if !strings.Contains(diags[0].Detail, "updated") {
+		t.Errorf("expected the error to be about the update, got %q", diags[0].Detail)
+	}
+}
+
+func TestHarnessEnvInvalidConfig(t *testing.T) {
+	h := newTestHarness(t, "cache_env")
+	region := envName("CACHE_HARNESS_REGION")
+	assertDiagnostic(t, h.validate(envConfig(region, region)), tfprotov5.DiagnosticSeverityError, "Duplicate variable")
+
+	conflicting := envName("CACHE_HARNESS_REGION")
+	conflicting["required"] = tftypes.NewValue(tftypes.Bool, true)
+	conflicting["default"] = tftypes.NewValue(tftypes.String, "eu-west-1")
+	assertDiagnostic(t, h.validate(envConfig(conflicting)), tfprotov5.DiagnosticSeverityError, "Conflicting variable settings")
+}
+
+func TestHarnessStoreCapturedThenNull(t *testing.T) {
+	for _, when := range []string{captureAlways, captureKnownAndNotNull} {
+		t.Run(when, func(t *testing.T) {
+			h := newTestHarness(t, "cache_store")
+			captureWhen := tftypes.NewValue(tftypes.String, when)
+			cached := tftypes.NewValue(tftypes.String, "ami-1")
+
+			assertNoDiagnostics(t, h.step(map[string]tftypes.Value{"value": cached, "capture_when": captureWhen}))
+			fingerprint := h.state["fingerprint"]
+
+			// The value is gone, e.g. because the image it was read from was deleted.
+			diags := h.step(map[string]tftypes.Value{"value": tftypes.NewValue(tftypes.DynamicPseudoType, nil), "capture_when": captureWhen})
+			if hasErrors(diags) {
+				t.Fatalf("unexpected errors: %s", diagnosticSummaries(diags))
+			}
+			for _, d := range diags {
+				if strings.Contains(d.Detail, "approve_fingerprint") {
+					t.Errorf("a null value cannot be approved: %s", d.Detail)
+				}
+			}
+			if when == captureKnownAndNotNull && len(diags) > 0 {
+				t.Errorf("a null value is expected with %s: %s", when, diagnosticSummaries(diags))
+			}
+			if !h.state["result"].Equal(cached) || !h.state["fingerprint"].Equal(fingerprint) {
+				t.Errorf("the cached value should be held in result, got %s", h.state["result"])
+			}
+
+			// A differing value configured again does not replace the held value.
+			diags = h.step(map[string]tftypes.Value{"value": tftypes.NewValue(tftypes.String, "ami-2"), "capture_when": captureWhen})
+			assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityWarning, "differs from cached value")
+			if !h.state["result"].Equal(cached) {
+				t.Errorf("the cached value should still be held in result, got %s", h.state["result"])
+			}
+
+			// Once the cached value is configured again, it is back in 'value'.
+			assertNoDiagnostics(t, h.step(map[string]tftypes.Value{"value": cached, "capture_when": captureWhen}))
+			if !h.state["value"].Equal(cached) || !h.state["result"].IsNull() {
+				t.Errorf("expected value %s without result, got value %s and result %s", cached, h.state["value"], h.state["result"])
+			}
+		})
+	}
+}
+
+func TestHarnessStoreStrictNull(t *testing.T) {
+	h := newTestHarness(t, "cache_store")
+	strict := tftypes.NewValue(tftypes.Bool, true)
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{"value": tftypes.NewValue(tftypes.String, "ami-1"), "strict": strict}))
+	diags := h.step(map[string]tftypes.Value{"value": tftypes.NewValue(tftypes.DynamicPseudoType, nil), "strict": strict})
+	assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityError, "cannot be changed")
+}
+
+func TestHarnessStoreStrictNestedChange(t *testing.T) {
+	h := newTestHarness(t, "cache_store")
+	strict := tftypes.NewValue(tftypes.Bool, true)
+	object := func(imageID string) tftypes.Value {
+		return tftypes.NewValue(testObjectType, map[string]tftypes.Value{
+			"image_id": tftypes.NewValue(tftypes.String, imageID),
+			"count":    tftypes.NewValue(tftypes.Number, 2),
+		})
+	}
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{"value": object("ami-1"), "strict": strict}))
+	state := h.state
+
+	diags := h.step(map[string]tftypes.Value{"value": object("ami-2"), "strict": strict})
+	assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityError, "cannot be changed")
+	want := tftypes.NewAttributePath().WithAttributeName("value").WithAttributeName("image_id")
+	if len(diags) != 1 || !diags[0].Attribute.Equal(want) {
+		t.Errorf("expected a single error at %s, got %s", want, diagnosticSummaries(diags))
+	}
+	if !h.state["value"].Equal(state["value"]) {
+		t.Errorf("a strict entry should keep its value, got %s", h.state["value"])
+	}
+}
+
+// captureCount returns the 'capture_count' of the harness state.
+func (h *testHarness) captureCount() int64 {
+	h.t.Helper()
+	var count big.Float
+	if err := h.state["capture_count"].As(&count); err != nil {
+		h.t.Fatal(err)
+	}
+	n, _ := count.Int64()
+	return n
+}
+
+func TestHarnessStoreApprove(t *testing.T) {
+	h := newTestHarness(t, "cache_store")
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{"value": tftypes.NewValue(tftypes.String, "ami-1")}))
+	if n := h.captureCount(); n != 1 {
+		t.Fatalf("expected a capture count of 1, got %d", n)
+	}
+
+	next := tftypes.NewValue(tftypes.String, "ami-2")
+	fingerprint := valueFingerprint(next)
+	diags := h.step(map[string]tftypes.Value{"value": next})
+	assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityWarning, "differs from cached value")
+	if !strings.Contains(diags[0].Detail, fingerprint) {
+		t.Errorf("expected the warning to report fingerprint %s, got %q", fingerprint, diags[0].Detail)
+	}
+
+	approve := tftypes.NewValue(tftypes.String, fingerprint)
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{"value": next, "approve_fingerprint": approve}))
+	if !h.state["value"].Equal(next) || !h.state["fingerprint"].Equal(approve) {
+		t.Errorf("expected the approved value %s to be captured, got %s", next, h.state["value"])
+	}
+	if n := h.captureCount(); n != 2 {
+		t.Errorf("expected a capture count of 2, got %d", n)
+	}
+	h.assertNoChanges(map[string]tftypes.Value{"value": next, "approve_fingerprint": approve})
+
+	// An approval left in place does not approve the next value.
+	diags = h.step(map[string]tftypes.Value{"value": tftypes.NewValue(tftypes.String, "ami-3"), "approve_fingerprint": approve})
+	assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityWarning, "differs from cached value")
+	if hasErrors(diags) {
+		t.Errorf("a stale approval should not be an error: %s", diagnosticSummaries(diags))
+	}
+	if !h.state["value"].Equal(next) {
+		t.Errorf("a stale approval should keep the cached value %s, got %s", next, h.state["value"])
+	}
+	if n := h.captureCount(); n != 2 {
+		t.Errorf("expected a capture count of 2, got %d", n)
+	}
+}
+
+func TestHarnessStoreApproveStrict(t *testing.T) {
+	h := newTestHarness(t, "cache_store")
+	strict := tftypes.NewValue(tftypes.Bool, true)
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{"value": tftypes.NewValue(tftypes.String, "ami-1"), "strict": strict}))
+
+	next := tftypes.NewValue(tftypes.String, "ami-2")
+	fingerprint := valueFingerprint(next)
+	diags := h.step(map[string]tftypes.Value{"value": next, "strict": strict})
+	assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityError, "cannot be changed")
+	if !strings.Contains(diags[0].Detail, fingerprint) {
+		t.Errorf("expected the error to report fingerprint %s, got %q", fingerprint, diags[0].Detail)
+	}
+
+	// An approval takes precedence over strict.
+	approve := tftypes.NewValue(tftypes.String, fingerprint)
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{"value": next, "strict": strict, "approve_fingerprint": approve}))
+	if !h.state["value"].Equal(next) {
+		t.Errorf("expected the approved value %s to be captured, got %s", next, h.state["value"])
+	}
+	if n := h.captureCount(); n != 2 {
+		t.Errorf("expected a capture count of 2, got %d", n)
+	}
+}
@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+const (
+	timestampUnix        = "unix"
+	timestampRFC3339     = "rfc3339"
+	timestampRFC3339Nano = "rfc3339nano"
+)
+
+// storeMetadataAttributes are recorded each time a cache_store captures a value.
+var storeMetadataAttributes = map[string]tftypes.Type{
+	"created_at":        tftypes.String,
+	"terraform_version": tftypes.String,
+	"workspace":         tftypes.String,
+	"captured_by":       tftypes.Map{ElementType: tftypes.String},
+	"capture_count":     tftypes.Number,
+}
+
+// formatTimestamp formats t for the 'timestamp' attribute. The default format is Unix seconds.
+func formatTimestamp(t time.Time, format string) (string, error) {
+	switch format {
+	case "", timestampUnix:
+		return fmt.Sprint(t.Unix()), nil
+	case timestampRFC3339:
+		return t.UTC().Format(time.RFC3339), nil
+	case timestampRFC3339Nano:
+		return t.UTC().Format(time.RFC3339Nano), nil
+	}
+	return "", fmt.Errorf("timestamp_format must be %q, %q or %q, got %q", timestampUnix, timestampRFC3339, timestampRFC3339Nano, format)
+}
+
+// planStoreMetadata plans the metadata of a new capture whenever the timestamp will change,
+// and reformats the timestamp of the current capture when only 'timestamp_format' changed.
+func planStoreMetadata(priorVal, proposedVal map[string]tftypes.Value) {
+	if !proposedVal["timestamp"].IsKnown() {
+		for name, typ := range storeMetadataAttributes {
+			proposedVal[name] = tftypes.NewValue(typ, tftypes.UnknownValue)
+		}
+		return
+	}
+	if proposedVal["timestamp_format"].Equal(priorVal["timestamp_format"]) {
+		return
+	}
+	created, ok := capturedAt(priorVal)
+	if !ok || !proposedVal["timestamp_format"].IsKnown() {
+		proposedVal["timestamp"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+		return
+	}
+	var format string
+	_ = proposedVal["timestamp_format"].As(&format)
+	if ts, err := formatTimestamp(created, format); err == nil {
+		proposedVal["timestamp"] = tftypes.NewValue(tftypes.String, ts)
+	}
+}
+
+// applyStoreMetadata records when, where and by whom a value was captured.
+func (s *RawProviderServer) applyStoreMetadata(priorState tftypes.Value, plannedVal map[string]tftypes.Value) {
+	created, ok := capturedAt(plannedVal)
+	if !plannedVal["created_at"].IsKnown() || !ok {
+		created = s.now().UTC()
+		plannedVal["created_at"] = tftypes.NewValue(tftypes.String, created.Format(time.RFC3339Nano))
+
+		plannedVal["terraform_version"] = tftypes.NewValue(tftypes.String, nil)
+		if s.hostTFVersion != "" {
+			plannedVal["terraform_version"] = tftypes.NewValue(tftypes.String, strings.TrimPrefix(s.hostTFVersion, "v"))
+		}
+
+		workspace, ok := os.LookupEnv("TF_WORKSPACE")
+		if !ok || workspace == "" {
+			workspace = "default"
+		}
+		plannedVal["workspace"] = tftypes.NewValue(tftypes.String, workspace)
+
+		capturedBy := map[string]tftypes.Value{}
+		for _, name := range s.capturedByEnv {
+			if v, ok := os.LookupEnv(name); ok {
+				capturedBy[name] = tftypes.NewValue(tftypes.String, v)
+			}
+		}
+		plannedVal["captured_by"] = tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, capturedBy)
+
+		var count int64
+		if !priorState.IsNull() {
+			priorVal := map[string]tftypes.Value{}
+			_ = priorState.As(&priorVal)
+			if c := priorVal["capture_count"]; c.IsKnown() && !c.IsNull() {
+				n := new(big.Float)
+				_ = c.As(&n)
+				count, _ = n.Int64()
+			}
+		}
+		var captured bool
+		_ = plannedVal["captured"].As(&captured)
+		if captured {
+			count++
+		}
+		plannedVal["capture_count"] = tftypes.NewValue(tftypes.Number, count)
+	}
+
+	if !plannedVal["timestamp"].IsKnown() {
+		var format string
+		_ = plannedVal["timestamp_format"].As(&format)
+		ts, err := formatTimestamp(created, format)
+		if err != nil {
+			ts = fmt.Sprint(created.Unix())
+		}
+		plannedVal["timestamp"] = tftypes.NewValue(tftypes.String, ts)
+	}
+}
+
+// capturedAt returns the time the cached value was captured, from 'created_at',
+// or from a 'timestamp' in Unix seconds for states that predate it.
+func capturedAt(stateVal map[string]tftypes.Value) (time.Time, bool) {
+	var created string
+	if v := stateVal["created_at"]; v.IsKnown() && !v.IsNull() {
+		_ = v.As(&created)
+		if t, err := time.Parse(time.RFC3339Nano, created); err == nil {
+			return t, true
+		}
+	}
+	var ts string
+	if err := stateVal["timestamp"].As(&ts); err != nil || ts == "" {
+		return time.Time{}, false
+	}
+	secs, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(secs, 0), true
+}
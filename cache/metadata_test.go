@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestApplyStoreRecordsMetadata(t *testing.T) {
+	t.Setenv("TF_WORKSPACE", "staging")
+	t.Setenv("CI_JOB_ID", "42")
+	s := &RawProviderServer{
+		logger:        hclog.NewNullLogger(),
+		hostTFVersion: "v1.3.0",
+		capturedByEnv: []string{"CI_JOB_ID", "UNSET_VARIABLE"},
+	}
+	planned := map[string]tftypes.Value{
+		"value":            tftypes.NewValue(tftypes.String, "ami-123"),
+		"timestamp_format": tftypes.NewValue(tftypes.String, timestampRFC3339),
+		"captured":         tftypes.NewValue(tftypes.Bool, true),
+		"fingerprint":      unknownString,
+		"timestamp":        unknownString,
+	}
+	for name, typ := range storeMetadataAttributes {
+		planned[name] = tftypes.NewValue(typ, tftypes.UnknownValue)
+	}
+	resp, err := s.ApplyResourceChange(context.Background(), &tfprotov5.ApplyResourceChangeRequest{
+		TypeName:     "cache_store",
+		PriorState:   encodeState(t, "cache_store", nil),
+		PlannedState: encodeState(t, "cache_store", planned),
+		Config:       encodeState(t, "cache_store", map[string]tftypes.Value{"value": planned["value"]}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := decodeState(t, "cache_store", resp.NewState)
+
+	var createdAt, timestamp string
+	_ = state["created_at"].As(&createdAt)
+	_ = state["timestamp"].As(&timestamp)
+	created, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		t.Fatalf("created_at %q: %s", createdAt, err)
+	}
+	if timestamp != created.Format(time.RFC3339) {
+		t.Errorf("timestamp %q does not match created_at %q", timestamp, createdAt)
+	}
+	expected := map[string]tftypes.Value{
+		"terraform_version": tftypes.NewValue(tftypes.String, "1.3.0"),
+		"workspace":         tftypes.NewValue(tftypes.String, "staging"),
+		"captured_by": tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, map[string]tftypes.Value{
+			"CI_JOB_ID": tftypes.NewValue(tftypes.String, "42"),
+		}),
+		"capture_count": tftypes.NewValue(tftypes.Number, 1),
+	}
+	for name, v := range expected {
+		if !state[name].Equal(v) {
+			t.Errorf("%s is %s, expected %s", name, state[name], v)
+		}
+	}
+}
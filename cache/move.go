@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// moveSource extracts the pinned value of a resource from its JSON state, and the time it was pinned if the state records one.
+type moveSource func(attrs map[string]json.RawMessage) (tftypes.Value, *time.Time, error)
+
+// moveSources are the resources that are commonly used to pin values, and which can be moved into a cache_store.
+var moveSources = map[string]moveSource{
+	"terraform_data": moveFromTerraformData,
+	"null_resource":  moveFromNullResource,
+	"time_static":    moveFromTimeStatic,
+}
+
+// MoveResourceState converts the state of a resource that pinned a value into a cache_store, keeping the pinned value frozen.
+func (s *RawProviderServer) MoveResourceState(ctx context.Context, req *tfprotov5.MoveResourceStateRequest) (*tfprotov5.MoveResourceStateResponse, error) {
+	resp := &tfprotov5.MoveResourceStateResponse{}
+
+	if req.TargetTypeName != "cache_store" {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  "Unsupported move target",
+			Detail:   fmt.Sprintf("Resources can only be moved into a cache_store, not a %s.", req.TargetTypeName),
+		})
+		return resp, nil
+	}
+	source, ok := moveSources[req.SourceTypeName]
+	if !ok {
+		names := make([]string, 0, len(moveSources))
+		for name := range moveSources {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  "Unsupported move source",
+			Detail:   fmt.Sprintf("A %s cannot be moved into a cache_store. Supported resources are %s.", req.SourceTypeName, strings.Join(names, ", ")),
+		})
+		return resp, nil
+	}
+	if req.SourceState == nil || req.SourceState.JSON == nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  "Failed to decode source state",
+			Detail:   "Only JSON state can be moved.",
+		})
+		return resp, nil
+	}
+
+	attrs := map[string]json.RawMessage{}
+	if err := json.Unmarshal(req.SourceState.JSON, &attrs); err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  "Failed to decode source state",
+			Detail:   err.Error(),
+		})
+		return resp, nil
+	}
+	value, pinnedAt, err := source(attrs)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  fmt.Sprintf("Failed to move %s", req.SourceTypeName),
+			Detail:   err.Error(),
+		})
+		return resp, nil
+	}
+
+	// Resources without a record of when they were created are treated as captured now.
+	created := time.Now().UTC()
+	if pinnedAt != nil {
+		created = pinnedAt.UTC()
+	}
+
+	rt, err := GetResourceType("cache_store")
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  "Failed to determine resource type",
+			Detail:   err.Error(),
+		})
+		return resp, nil
+	}
+	vals := map[string]tftypes.Value{}
+	for name, typ := range rt.(tftypes.Object).AttributeTypes {
+		vals[name] = tftypes.NewValue(typ, nil)
+	}
+	vals["value"] = value
+	vals["captured"] = tftypes.NewValue(tftypes.Bool, true)
+	vals["fingerprint"] = tftypes.NewValue(tftypes.String, valueFingerprint(value))
+	vals["created_at"] = tftypes.NewValue(tftypes.String, created.Format(time.RFC3339Nano))
+	vals["timestamp"] = tftypes.NewValue(tftypes.String, fmt.Sprint(created.Unix()))
+	vals["capture_count"] = tftypes.NewValue(tftypes.Number, 1)
+
+	state, err := tfprotov5.NewDynamicValue(rt, tftypes.NewValue(rt, vals))
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  "Failed to encode moved state",
+			Detail:   err.Error(),
+		})
+		return resp, nil
+	}
+	resp.TargetState = &state
+	return resp, nil
+}
+
+// moveFromTerraformData pins the 'output' of a terraform_data, which is its 'input' as of the last apply.
+func moveFromTerraformData(attrs map[string]json.RawMessage) (tftypes.Value, *time.Time, error) {
+	for _, name := range []string{"output", "input"} {
+		raw, ok := attrs[name]
+		if !ok {
+			continue
+		}
+		v, err := tftypes.ValueFromJSON(raw, tftypes.DynamicPseudoType)
+		if err != nil {
+			return tftypes.Value{}, nil, fmt.Errorf("decoding %s: %w", name, err)
+		}
+		if !v.IsNull() {
+			return v, nil, nil
+		}
+	}
+	return tftypes.NewValue(tftypes.DynamicPseudoType, nil), nil, nil
+}
+
+// moveFromNullResource pins the 'triggers' of a null_resource.
+func moveFromNullResource(attrs map[string]json.RawMessage) (tftypes.Value, *time.Time, error) {
+	raw, ok := attrs["triggers"]
+	if !ok {
+		return tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, nil), nil, nil
+	}
+	v, err := tftypes.ValueFromJSON(raw, tftypes.Map{ElementType: tftypes.String})
+	if err != nil {
+		return tftypes.Value{}, nil, fmt.Errorf("decoding triggers: %w", err)
+	}
+	return v, nil, nil
+}
+
+// moveFromTimeStatic pins the 'rfc3339' of a time_static, which is also when it was pinned.
+func moveFromTimeStatic(attrs map[string]json.RawMessage) (tftypes.Value, *time.Time, error) {
+	var ts string
+	if err := json.Unmarshal(attrs["rfc3339"], &ts); err != nil || ts == "" {
+		return tftypes.Value{}, nil, fmt.Errorf("the state has no rfc3339 timestamp")
+	}
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return tftypes.Value{}, nil, fmt.Errorf("decoding rfc3339: %w", err)
+	}
+	return tftypes.NewValue(tftypes.String, ts), &t, nil
+}
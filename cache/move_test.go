@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestMoveResourceState(t *testing.T) {
+	cases := map[string]struct {
+		source    string
+		state     string
+		value     tftypes.Value
+		timestamp string
+	}{
+		"terraform_data": {
+			source: "terraform_data",
+			state:  `{"id":"x","input":{"value":"ami-123","type":"string"},"output":{"value":"ami-123","type":"string"},"triggers_replace":null}`,
+			value:  tftypes.NewValue(tftypes.String, "ami-123"),
+		},
+		"null_resource": {
+			source: "null_resource",
+			state:  `{"id":"123","triggers":{"ami":"ami-123"}}`,
+			value: tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, map[string]tftypes.Value{
+				"ami": tftypes.NewValue(tftypes.String, "ami-123"),
+			}),
+		},
+		"time_static": {
+			source:    "time_static",
+			state:     `{"id":"2021-10-12T00:53:20Z","rfc3339":"2021-10-12T00:53:20Z","unix":1634000000,"triggers":null}`,
+			value:     tftypes.NewValue(tftypes.String, "2021-10-12T00:53:20Z"),
+			timestamp: "1634000000",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := &RawProviderServer{logger: hclog.NewNullLogger()}
+			resp, err := s.MoveResourceState(context.Background(), &tfprotov5.MoveResourceStateRequest{
+				SourceTypeName: c.source,
+				SourceState:    &tfprotov5.RawState{JSON: []byte(c.state)},
+				TargetTypeName: "cache_store",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Diagnostics) > 0 {
+				t.Fatalf("unexpected diagnostic: %s: %s", resp.Diagnostics[0].Summary, resp.Diagnostics[0].Detail)
+			}
+			state := decodeState(t, "cache_store", resp.TargetState)
+
+			if !state["value"].Equal(c.value) {
+				t.Errorf("moved value %s, expected %s", state["value"], c.value)
+			}
+			if !state["fingerprint"].Equal(tftypes.NewValue(tftypes.String, valueFingerprint(c.value))) {
+				t.Errorf("fingerprint %s does not match the moved value", state["fingerprint"])
+			}
+			if c.timestamp != "" && !state["timestamp"].Equal(tftypes.NewValue(tftypes.String, c.timestamp)) {
+				t.Errorf("timestamp %s, expected the original %s", state["timestamp"], c.timestamp)
+			}
+		})
+	}
+}
+
+func TestMoveResourceStateUnsupportedSource(t *testing.T) {
+	s := &RawProviderServer{logger: hclog.NewNullLogger()}
+	resp, err := s.MoveResourceState(context.Background(), &tfprotov5.MoveResourceStateRequest{
+		SourceTypeName: "random_id",
+		SourceState:    &tfprotov5.RawState{JSON: []byte(`{}`)},
+		TargetTypeName: "cache_store",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Diagnostics) == 0 || resp.TargetState != nil {
+		t.Error("moving an unsupported resource should fail")
+	}
+}
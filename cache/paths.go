@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// valuePaths are dotted paths into a value, such as "tags.owner" or "subnets.0", split into their segments.
+type valuePaths [][]string
+
+// parseValuePaths decodes a list of dotted paths. It reports false while the list is not fully known.
+func parseValuePaths(list tftypes.Value) (valuePaths, bool, error) {
+	if !list.IsFullyKnown() {
+		return nil, false, nil
+	}
+	var elems []tftypes.Value
+	_ = list.As(&elems)
+
+	paths := make(valuePaths, 0, len(elems))
+	for _, e := range elems {
+		var p string
+		_ = e.As(&p)
+		segments := strings.Split(p, ".")
+		for _, s := range segments {
+			if s == "" {
+				return nil, true, fmt.Errorf("%q is not a valid path, segments must not be empty", p)
+			}
+		}
+		paths = append(paths, segments)
+	}
+	return paths, true, nil
+}
+
+// pathSegments converts the steps of an attribute path into dotted path segments.
+func pathSegments(p *tftypes.AttributePath) []string {
+	steps := p.Steps()
+	segments := make([]string, 0, len(steps))
+	for _, step := range steps {
+		switch s := step.(type) {
+		case tftypes.AttributeName:
+			segments = append(segments, string(s))
+		case tftypes.ElementKeyString:
+			segments = append(segments, string(s))
+		case tftypes.ElementKeyInt:
+			segments = append(segments, strconv.FormatInt(int64(s), 10))
+		default:
+			// set elements cannot be addressed by a dotted path
+			segments = append(segments, "\x00")
+		}
+	}
+	return segments
+}
+
+func isPrefix(prefix, segments []string) bool {
+	if len(prefix) > len(segments) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// matches reports whether p is exactly one of the paths.
+func (paths valuePaths) matches(p *tftypes.AttributePath) bool {
+	segments := pathSegments(p)
+	for _, path := range paths {
+		if len(path) == len(segments) && isPrefix(path, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// covers reports whether p is one of the paths or lies within one of them.
+func (paths valuePaths) covers(p *tftypes.AttributePath) bool {
+	segments := pathSegments(p)
+	for _, path := range paths {
+		if isPrefix(path, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps reports whether p is covered by one of the paths or contains one of them.
+func (paths valuePaths) overlaps(p *tftypes.AttributePath) bool {
+	segments := pathSegments(p)
+	for _, path := range paths {
+		if isPrefix(path, segments) || isPrefix(segments, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// withAttributePrefix roots a path within a value at the named attribute of the resource.
+func withAttributePrefix(name string, p *tftypes.AttributePath) *tftypes.AttributePath {
+	return tftypes.NewAttributePathWithSteps(append([]tftypes.AttributePathStep{tftypes.AttributeName(name)}, p.Steps()...))
+}
+
+// freezePaths returns pending with the given paths taken from cached.
+// Paths missing from either value, or whose type changed, keep the pending value and are returned as conflicts.
+func freezePaths(pending, cached tftypes.Value, paths valuePaths) (tftypes.Value, []*tftypes.AttributePath, error) {
+	var conflicts []*tftypes.AttributePath
+	// Transform only visits the paths of pending, so paths missing from it are found separately.
+	// Paths below an unknown value may still turn up once it is known.
+	for _, segments := range paths {
+		if _, p, found := lookupValuePath(pending, segments); !found {
+			conflicts = append(conflicts, p)
+		}
+	}
+	merged, err := tftypes.Transform(pending, func(p *tftypes.AttributePath, v tftypes.Value) (tftypes.Value, error) {
+		if !paths.matches(p) {
+			return v, nil
+		}
+		found, _, err := tftypes.WalkAttributePath(cached, p)
+		if err != nil {
+			conflicts = append(conflicts, p)
+			return v, nil
+		}
+		frozen, ok := found.(tftypes.Value)
+		if !ok || !frozen.Type().Equal(v.Type()) {
+			conflicts = append(conflicts, p)
+			return v, nil
+		}
+		return frozen, nil
+	})
+	return merged, conflicts, err
+}
+
+// maskPaths returns v with the given paths set to null, so that they are left out of fingerprints.
+func maskPaths(v tftypes.Value, paths valuePaths) tftypes.Value {
+	if len(paths) == 0 {
+		return v
+	}
+	masked, err := tftypes.Transform(v, func(p *tftypes.AttributePath, v tftypes.Value) (tftypes.Value, error) {
+		if paths.covers(p) {
+			return tftypes.NewValue(v.Type(), nil), nil
+		}
+		return v, nil
+	})
+	if err != nil {
+		return v
+	}
+	return masked
+}
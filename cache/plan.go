@@ -2,7 +2,6 @@ package cache
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
 	"github.com/hashicorp/terraform-plugin-go/tftypes"
@@ -75,40 +74,49 @@ func (s *RawProviderServer) PlanResourceChange(ctx context.Context, req *tfproto
 
 	if proposedState.IsNull() {
 		// we plan to delete the resource
-		if _, ok := priorVal["timestamp"]; ok {
-			resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
-				Severity: tfprotov5.DiagnosticSeverityError,
-				Summary:  "Invalid prior state while planning for destroy",
-				Detail:   fmt.Sprintf("'timestamp' attribute missing from state: %s", err),
-			})
+		if priorState.IsNull() {
+			resp.PlannedState = req.ProposedNewState
 			return resp, nil
 		}
+		if req.TypeName == "cache_store" {
+			planStoreDestroy(priorVal, s.now(), resp)
+			if hasErrors(resp.Diagnostics) {
+				return resp, nil
+			}
+		}
 		resp.PlannedState = req.ProposedNewState
 		return resp, nil
 	}
 
-	if proposedVal["timestamp"].IsNull() {
-		// plan for Create
-		proposedVal["timestamp"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
-		propStateVal := tftypes.NewValue(proposedState.Type(), proposedVal)
-		s.logger.Trace("[PlanResourceChange]", "new planned state", dump(propStateVal))
+	switch req.TypeName {
+	case "cache_version_pin":
+		s.planVersionPin(priorState, priorVal, proposedVal, resp)
+	case "cache_file":
+		s.planFile(priorState, priorVal, proposedVal, resp)
+	case "cache_command":
+		s.planCommand(priorState, priorVal, proposedVal, resp)
+	case "cache_env":
+		s.planEnv(priorState, priorVal, proposedVal, resp)
+	default:
+		s.planStore(priorState, priorVal, proposedVal, resp)
+	}
+	if hasErrors(resp.Diagnostics) {
+		return resp, nil
+	}
 
-		plannedState, err := tfprotov5.NewDynamicValue(rt, propStateVal)
-		if err != nil {
-			resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
-				Severity: tfprotov5.DiagnosticSeverityError,
-				Summary:  "Failed to assemble proposed state during plan",
-				Detail:   err.Error(),
-			})
-			return resp, nil
-		}
+	propStateVal := tftypes.NewValue(rt, proposedVal)
+	s.logger.Trace("[PlanResourceChange]", "new planned state", dump(propStateVal))
 
-		resp.PlannedState = &plannedState
-	} else {
-		// plan for Update
-		// NO-OP
-		resp.PlannedState = req.PriorState
+	plannedState, err := tfprotov5.NewDynamicValue(rt, propStateVal)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  "Failed to assemble proposed state during plan",
+			Detail:   err.Error(),
+		})
+		return resp, nil
 	}
 
+	resp.PlannedState = &plannedState
 	return resp, nil
 }
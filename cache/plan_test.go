@@ -0,0 +1,387 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+var (
+	testObjectType = tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"image_id": tftypes.String,
+		"count":    tftypes.Number,
+	}}
+	unknownString = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+)
+
+// encodeState encodes the attributes of a resource, setting any attribute that is not given to null.
+// A nil map encodes a null resource.
+func encodeState(t *testing.T, typeName string, vals map[string]tftypes.Value) *tfprotov5.DynamicValue {
+	t.Helper()
+	rt, err := GetResourceType(typeName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := tftypes.NewValue(rt, nil)
+	if vals != nil {
+		full := map[string]tftypes.Value{}
+		for k, typ := range rt.(tftypes.Object).AttributeTypes {
+			full[k] = tftypes.NewValue(typ, nil)
+		}
+		for k, val := range vals {
+			full[k] = val
+		}
+		v = tftypes.NewValue(rt, full)
+	}
+	dv, err := tfprotov5.NewDynamicValue(rt, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &dv
+}
+
+func decodeState(t *testing.T, typeName string, dv *tfprotov5.DynamicValue) map[string]tftypes.Value {
+	t.Helper()
+	if dv == nil {
+		t.Fatal("no state returned")
+	}
+	rt, err := GetResourceType(typeName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := dv.Unmarshal(rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals := map[string]tftypes.Value{}
+	if err := v.As(&vals); err != nil {
+		t.Fatal(err)
+	}
+	return vals
+}
+
+func planStoreChange(t *testing.T, prior, config map[string]tftypes.Value) *tfprotov5.PlanResourceChangeResponse {
+	t.Helper()
+	resp := planStoreChangeAllowingErrors(t, prior, config)
+	for _, d := range resp.Diagnostics {
+		if d.Severity == tfprotov5.DiagnosticSeverityError {
+			t.Fatalf("unexpected error: %s: %s", d.Summary, d.Detail)
+		}
+	}
+	return resp
+}
+
+func planStoreChangeAllowingErrors(t *testing.T, prior, config map[string]tftypes.Value) *tfprotov5.PlanResourceChangeResponse {
+	t.Helper()
+	s := &RawProviderServer{logger: hclog.NewNullLogger()}
+
+	// Terraform proposes the prior values for computed attributes that are not configured.
+	proposed := map[string]tftypes.Value{}
+	for k, v := range prior {
+		proposed[k] = v
+	}
+	for k, v := range config {
+		proposed[k] = v
+	}
+
+	resp, err := s.PlanResourceChange(context.Background(), &tfprotov5.PlanResourceChangeRequest{
+		TypeName:         "cache_store",
+		PriorState:       encodeState(t, "cache_store", prior),
+		ProposedNewState: encodeState(t, "cache_store", proposed),
+		Config:           encodeState(t, "cache_store", config),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestPlanStoreCreateWithUnknownValue(t *testing.T) {
+	cases := map[string]tftypes.Value{
+		"unknown": tftypes.NewValue(tftypes.DynamicPseudoType, tftypes.UnknownValue),
+		"partially unknown object": tftypes.NewValue(testObjectType, map[string]tftypes.Value{
+			"image_id": unknownString,
+			"count":    tftypes.NewValue(tftypes.Number, 2),
+		}),
+		"list with unknown element": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+			tftypes.NewValue(tftypes.String, "a"),
+			unknownString,
+		}),
+	}
+	for name, value := range cases {
+		t.Run(name, func(t *testing.T) {
+			resp := planStoreChange(t, nil, map[string]tftypes.Value{"value": value})
+			planned := decodeState(t, "cache_store", resp.PlannedState)
+
+			if !planned["value"].Equal(value) {
+				t.Errorf("planned value %s, expected the configured %s", planned["value"], value)
+			}
+			for _, attr := range []string{"timestamp", "fingerprint"} {
+				if planned[attr].IsKnown() {
+					t.Errorf("%s should be unknown while the value is not fully known, got %s", attr, planned[attr])
+				}
+			}
+		})
+	}
+}
+
+func TestPlanStoreCreateWithKnownValue(t *testing.T) {
+	value := tftypes.NewValue(tftypes.String, "ami-123")
+	resp := planStoreChange(t, nil, map[string]tftypes.Value{"value": value})
+	planned := decodeState(t, "cache_store", resp.PlannedState)
+
+	if !planned["fingerprint"].Equal(tftypes.NewValue(tftypes.String, valueFingerprint(value))) {
+		t.Errorf("fingerprint of a known value should be planned, got %s", planned["fingerprint"])
+	}
+	if planned["timestamp"].IsKnown() {
+		t.Errorf("timestamp should only be known after apply, got %s", planned["timestamp"])
+	}
+}
+
+func TestPlanStoreUpdateWithUnknownValue(t *testing.T) {
+	cached := tftypes.NewValue(testObjectType, map[string]tftypes.Value{
+		"image_id": tftypes.NewValue(tftypes.String, "ami-123"),
+		"count":    tftypes.NewValue(tftypes.Number, 2),
+	})
+	prior := map[string]tftypes.Value{
+		"value":       cached,
+		"timestamp":   tftypes.NewValue(tftypes.String, "1634000000"),
+		"fingerprint": tftypes.NewValue(tftypes.String, valueFingerprint(cached)),
+		"captured":    tftypes.NewValue(tftypes.Bool, true),
+	}
+
+	cases := map[string]tftypes.Value{
+		"unknown": tftypes.NewValue(tftypes.DynamicPseudoType, tftypes.UnknownValue),
+		"partially unknown object": tftypes.NewValue(testObjectType, map[string]tftypes.Value{
+			"image_id": unknownString,
+			"count":    tftypes.NewValue(tftypes.Number, 2),
+		}),
+		"partially unknown object with changes": tftypes.NewValue(testObjectType, map[string]tftypes.Value{
+			"image_id": unknownString,
+			"count":    tftypes.NewValue(tftypes.Number, 3),
+		}),
+	}
+	for name, value := range cases {
+		t.Run(name, func(t *testing.T) {
+			resp := planStoreChange(t, prior, map[string]tftypes.Value{"value": value})
+			planned := decodeState(t, "cache_store", resp.PlannedState)
+
+			for attr, priorVal := range prior {
+				if !planned[attr].IsFullyKnown() {
+					t.Errorf("%s should be planned from prior state, got %s", attr, planned[attr])
+				} else if !planned[attr].Equal(priorVal) {
+					t.Errorf("%s planned as %s, expected the prior %s", attr, planned[attr], priorVal)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanStorePendingWithUnknownValue(t *testing.T) {
+	prior := map[string]tftypes.Value{
+		"value":        tftypes.NewValue(tftypes.DynamicPseudoType, nil),
+		"capture_when": tftypes.NewValue(tftypes.String, captureKnownAndNotNull),
+		"captured":     tftypes.NewValue(tftypes.Bool, false),
+		"timestamp":    tftypes.NewValue(tftypes.String, "1634000000"),
+	}
+	resp := planStoreChange(t, prior, map[string]tftypes.Value{
+		"value":        tftypes.NewValue(tftypes.DynamicPseudoType, tftypes.UnknownValue),
+		"capture_when": tftypes.NewValue(tftypes.String, captureKnownAndNotNull),
+	})
+	planned := decodeState(t, "cache_store", resp.PlannedState)
+
+	for _, attr := range []string{"value", "captured", "timestamp", "fingerprint"} {
+		if planned[attr].IsKnown() {
+			t.Errorf("%s should be unknown until the value is known, got %s", attr, planned[attr])
+		}
+	}
+}
+
+func TestValidateStoreWithUnknownValue(t *testing.T) {
+	s := &RawProviderServer{logger: hclog.NewNullLogger()}
+	resp, err := s.ValidateResourceTypeConfig(context.Background(), &tfprotov5.ValidateResourceTypeConfigRequest{
+		TypeName: "cache_store",
+		Config: encodeState(t, "cache_store", map[string]tftypes.Value{
+			"value": tftypes.NewValue(testObjectType, map[string]tftypes.Value{
+				"image_id": unknownString,
+				"count":    tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
+			}),
+			"rotate_on": unknownString,
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Diagnostics) > 0 {
+		t.Errorf("unknown values should not produce diagnostics, got %s", resp.Diagnostics[0].Summary)
+	}
+}
+
+func TestApplyStoreWithUnknownPlannedValue(t *testing.T) {
+	s := &RawProviderServer{logger: hclog.NewNullLogger()}
+	final := tftypes.NewValue(testObjectType, map[string]tftypes.Value{
+		"image_id": tftypes.NewValue(tftypes.String, "ami-123"),
+		"count":    tftypes.NewValue(tftypes.Number, 2),
+	})
+	resp, err := s.ApplyResourceChange(context.Background(), &tfprotov5.ApplyResourceChangeRequest{
+		TypeName:   "cache_store",
+		PriorState: encodeState(t, "cache_store", nil),
+		PlannedState: encodeState(t, "cache_store", map[string]tftypes.Value{
+			"value": tftypes.NewValue(testObjectType, map[string]tftypes.Value{
+				"image_id": unknownString,
+				"count":    tftypes.NewValue(tftypes.Number, 2),
+			}),
+			"timestamp":   unknownString,
+			"fingerprint": unknownString,
+			"captured":    tftypes.NewValue(tftypes.Bool, true),
+		}),
+		Config: encodeState(t, "cache_store", map[string]tftypes.Value{"value": final}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := decodeState(t, "cache_store", resp.NewState)
+
+	if !state["value"].Equal(final) {
+		t.Errorf("stored value %s, expected %s", state["value"], final)
+	}
+	for attr, v := range state {
+		if !v.IsFullyKnown() {
+			t.Errorf("%s is not fully known after apply: %s", attr, v)
+		}
+	}
+}
+
+func TestPlanStoreFrozenPaths(t *testing.T) {
+	cached := tftypes.NewValue(testObjectType, map[string]tftypes.Value{
+		"image_id": tftypes.NewValue(tftypes.String, "ami-123"),
+		"count":    tftypes.NewValue(tftypes.Number, 2),
+	})
+	frozenPaths := tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+		tftypes.NewValue(tftypes.String, "image_id"),
+	})
+	prior := map[string]tftypes.Value{
+		"value":        cached,
+		"result":       cached,
+		"frozen_paths": frozenPaths,
+		"timestamp":    tftypes.NewValue(tftypes.String, "1634000000"),
+		"fingerprint":  tftypes.NewValue(tftypes.String, valueFingerprint(cached)),
+		"captured":     tftypes.NewValue(tftypes.Bool, true),
+	}
+	configured := tftypes.NewValue(testObjectType, map[string]tftypes.Value{
+		"image_id": tftypes.NewValue(tftypes.String, "ami-456"),
+		"count":    tftypes.NewValue(tftypes.Number, 3),
+	})
+	resp := planStoreChange(t, prior, map[string]tftypes.Value{
+		"value":        configured,
+		"frozen_paths": frozenPaths,
+	})
+	planned := decodeState(t, "cache_store", resp.PlannedState)
+
+	expected := tftypes.NewValue(testObjectType, map[string]tftypes.Value{
+		"image_id": tftypes.NewValue(tftypes.String, "ami-123"),
+		"count":    tftypes.NewValue(tftypes.Number, 3),
+	})
+	if !planned["value"].Equal(configured) {
+		t.Errorf("value should follow the configuration, got %s", planned["value"])
+	}
+	if !planned["result"].Equal(expected) {
+		t.Errorf("result planned as %s, expected %s", planned["result"], expected)
+	}
+	if len(resp.Diagnostics) != 1 || !resp.Diagnostics[0].Attribute.Equal(tftypes.NewAttributePath().WithAttributeName("value")) {
+		t.Errorf("expected a single warning about the frozen image_id, got %d diagnostics", len(resp.Diagnostics))
+	}
+}
+
+func TestPlanStoreIgnorePaths(t *testing.T) {
+	ignorePaths := tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+		tftypes.NewValue(tftypes.String, "count"),
+	})
+	cached := tftypes.NewValue(testObjectType, map[string]tftypes.Value{
+		"image_id": tftypes.NewValue(tftypes.String, "ami-123"),
+		"count":    tftypes.NewValue(tftypes.Number, 2),
+	})
+	configured := tftypes.NewValue(testObjectType, map[string]tftypes.Value{
+		"image_id": tftypes.NewValue(tftypes.String, "ami-123"),
+		"count":    tftypes.NewValue(tftypes.Number, 3),
+	})
+	if maskPaths(cached, valuePaths{{"count"}}).Equal(cached) {
+		t.Fatal("ignored path was not masked")
+	}
+	if valueFingerprint(maskPaths(cached, valuePaths{{"count"}})) != valueFingerprint(maskPaths(configured, valuePaths{{"count"}})) {
+		t.Error("ignored path changed the fingerprint")
+	}
+
+	prior := map[string]tftypes.Value{
+		"value":        cached,
+		"strict":       tftypes.NewValue(tftypes.Bool, true),
+		"ignore_paths": ignorePaths,
+		"timestamp":    tftypes.NewValue(tftypes.String, "1634000000"),
+		"captured":     tftypes.NewValue(tftypes.Bool, true),
+	}
+	resp := planStoreChange(t, prior, map[string]tftypes.Value{
+		"value":        configured,
+		"strict":       tftypes.NewValue(tftypes.Bool, true),
+		"ignore_paths": ignorePaths,
+	})
+	if len(resp.Diagnostics) > 0 {
+		t.Errorf("changes to ignored paths should not be reported, got %s", resp.Diagnostics[0].Summary)
+	}
+	planned := decodeState(t, "cache_store", resp.PlannedState)
+	if !planned["value"].Equal(cached) {
+		t.Errorf("value planned as %s, expected the cached %s", planned["value"], cached)
+	}
+}
+
+func TestFreezePathsMissingFromPending(t *testing.T) {
+	cached := tftypes.NewValue(testObjectType, map[string]tftypes.Value{
+		"image_id": tftypes.NewValue(tftypes.String, "ami-123"),
+		"count":    tftypes.NewValue(tftypes.Number, 2),
+	})
+	pendingType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{"count": tftypes.Number}}
+	pending := tftypes.NewValue(pendingType, map[string]tftypes.Value{
+		"count": tftypes.NewValue(tftypes.Number, 3),
+	})
+	merged, conflicts, err := freezePaths(pending, cached, valuePaths{{"image_id"}, {"count"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := tftypes.NewValue(pendingType, map[string]tftypes.Value{
+		"count": tftypes.NewValue(tftypes.Number, 2),
+	})
+	if !merged.Equal(expected) {
+		t.Errorf("merged %s, expected %s", merged, expected)
+	}
+	if len(conflicts) != 1 || !conflicts[0].Equal(tftypes.NewAttributePath().WithAttributeName("image_id")) {
+		t.Errorf("expected a conflict at image_id, got %v", conflicts)
+	}
+
+	// A path below an unknown value is not a conflict yet.
+	unknown := tftypes.NewValue(testObjectType, tftypes.UnknownValue)
+	if _, conflicts, _ := freezePaths(unknown, cached, valuePaths{{"image_id"}}); len(conflicts) != 0 {
+		t.Errorf("expected no conflicts below an unknown value, got %v", conflicts)
+	}
+}
+
+func TestHarnessStoreFrozenPathMissing(t *testing.T) {
+	h := newTestHarness(t, "cache_store")
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{"value": tftypes.NewValue(tftypes.String, "ami-1")}))
+
+	configured := tftypes.NewValue(tftypes.String, "ami-2")
+	diags := h.step(map[string]tftypes.Value{
+		"value":        configured,
+		"frozen_paths": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "a")}),
+	})
+	assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityWarning, "Frozen path cannot be kept")
+	for _, d := range diags {
+		if d.Summary == "Configured value differs from cached value" {
+			t.Errorf("nothing was kept, but the plan claims the cached value is: %s", d.Detail)
+		}
+	}
+	if !h.state["result"].Equal(configured) {
+		t.Errorf("result should follow the configuration, got %s", h.state["result"])
+	}
+}
@@ -13,7 +13,7 @@ var providerName = "terraform-provider-cache"
 
 // Serve is the default entrypoint for the provider.
 func Serve(ctx context.Context, logger hclog.Logger) error {
-	return tf5server.Serve(providerName, func() tfprotov5.ProviderServer { return &(RawProviderServer{logger: logger}) })
+	return tf5server.Serve(providerName, func() tfprotov5.ProviderServer { return newRawProviderServer(logger) })
 }
 
 // Provider
@@ -25,9 +25,9 @@ func Provider() func() tfprotov5.ProviderServer {
 	}
 
 	return func() tfprotov5.ProviderServer {
-		return &(RawProviderServer{logger: hclog.New(&hclog.LoggerOptions{
+		return newRawProviderServer(hclog.New(&hclog.LoggerOptions{
 			Level:  hclog.LevelFromString(logLevel),
 			Output: os.Stderr,
-		})})
+		}))
 	}
 }
@@ -43,9 +43,82 @@ func GetResourceType(name string) (tftypes.Type, error) {
 func GetProviderResourceSchema() map[string]*tfprotov5.Schema {
 	return map[string]*tfprotov5.Schema{
 		"cache_store": {
-			Version: 1,
+			Version: 2,
 			Block: &tfprotov5.SchemaBlock{
-				BlockTypes: []*tfprotov5.SchemaNestedBlock{},
+				BlockTypes: []*tfprotov5.SchemaNestedBlock{
+					{
+						TypeName: "validation",
+						Nesting:  tfprotov5.SchemaNestedBlockNestingModeList,
+						Block: &tfprotov5.SchemaBlock{
+							Attributes: []*tfprotov5.SchemaAttribute{
+								{
+									Name:        "path",
+									Type:        tftypes.String,
+									Required:    false,
+									Optional:    true,
+									Computed:    false,
+									Description: "A path such as `tags.owner` to validate within the value, instead of the whole value.",
+								},
+								{
+									Name:        "regex",
+									Type:        tftypes.String,
+									Required:    false,
+									Optional:    true,
+									Computed:    false,
+									Description: "A regular expression a string must match.",
+								},
+								{
+									Name:        "min",
+									Type:        tftypes.Number,
+									Required:    false,
+									Optional:    true,
+									Computed:    false,
+									Description: "The minimum of a number.",
+								},
+								{
+									Name:        "max",
+									Type:        tftypes.Number,
+									Required:    false,
+									Optional:    true,
+									Computed:    false,
+									Description: "The maximum of a number.",
+								},
+								{
+									Name:        "required_keys",
+									Type:        tftypes.List{ElementType: tftypes.String},
+									Required:    false,
+									Optional:    true,
+									Computed:    false,
+									Description: "Keys an object or map must have, with non-null values.",
+								},
+								{
+									Name:        "min_length",
+									Type:        tftypes.Number,
+									Required:    false,
+									Optional:    true,
+									Computed:    false,
+									Description: "The minimum number of elements of a list, set, tuple or map.",
+								},
+								{
+									Name:        "max_length",
+									Type:        tftypes.Number,
+									Required:    false,
+									Optional:    true,
+									Computed:    false,
+									Description: "The maximum number of elements of a list, set, tuple or map.",
+								},
+								{
+									Name:        "error_message",
+									Type:        tftypes.String,
+									Required:    false,
+									Optional:    true,
+									Computed:    false,
+									Description: "The error to show when the rule fails, instead of the default one.",
+								},
+							},
+						},
+					},
+				},
 				Attributes: []*tfprotov5.SchemaAttribute{
 					{
 						Name:        "timestamp",
@@ -63,6 +136,425 @@ func GetProviderResourceSchema() map[string]*tfprotov5.Schema {
 						Computed:    false,
 						Description: "The value to cache.",
 					},
+					{
+						Name:        "strict",
+						Type:        tftypes.Bool,
+						Required:    false,
+						Optional:    true,
+						Computed:    false,
+						Description: "Whether a configured value that differs from the cached value is an error instead of being ignored.",
+					},
+					{
+						Name:        "approve_fingerprint",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    true,
+						Computed:    false,
+						Description: "The fingerprint of a pending value that may replace the cached value.",
+					},
+					{
+						Name:        "fingerprint",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The SHA-256 fingerprint of the cached value",
+					},
+					{
+						Name:        "rotate_on",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    true,
+						Computed:    false,
+						Description: "A cron expression, evaluated in UTC, for when the cached value may be re-captured.",
+					},
+					{
+						Name:        "rotation_window",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    true,
+						Computed:    false,
+						Description: "How long after a scheduled rotation a plan may still re-capture the value, e.g. \"4h\".",
+					},
+					{
+						Name:        "next_rotation",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The first scheduled rotation after the value was captured",
+					},
+					{
+						Name:        "capture_when",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    true,
+						Computed:    false,
+						Description: "When the configured value is captured: `always` or `known_and_not_null`. Defaults to `always`.",
+					},
+					{
+						Name:        "captured",
+						Type:        tftypes.Bool,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "Whether a value has been captured",
+					},
+					{
+						Name:        "timestamp_format",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    true,
+						Computed:    false,
+						Description: "The format of `timestamp`: `unix`, `rfc3339` or `rfc3339nano`. Defaults to `unix`.",
+					},
+					{
+						Name:        "created_at",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "When the cached value was captured, in RFC3339 format with nanoseconds",
+					},
+					{
+						Name:        "terraform_version",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The version of Terraform that captured the value",
+					},
+					{
+						Name:        "workspace",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The workspace the value was captured in, from `TF_WORKSPACE`",
+					},
+					{
+						Name:        "captured_by",
+						Type:        tftypes.Map{ElementType: tftypes.String},
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The environment variables listed in the provider's `captured_by_env` when the value was captured",
+					},
+					{
+						Name:        "capture_count",
+						Type:        tftypes.Number,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "How many values this resource has captured",
+					},
+					{
+						Name:        "frozen_paths",
+						Type:        tftypes.List{ElementType: tftypes.String},
+						Required:    false,
+						Optional:    true,
+						Computed:    false,
+						Description: "Paths such as `image_id` or `tags.owner` to freeze within the value, instead of freezing the whole value.",
+					},
+					{
+						Name:        "ignore_paths",
+						Type:        tftypes.List{ElementType: tftypes.String},
+						Required:    false,
+						Optional:    true,
+						Computed:    false,
+						Description: "Paths within the value whose changes are neither reported nor part of the fingerprint.",
+					},
+					{
+						Name:        "type_constraint",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    true,
+						Computed:    false,
+						Description: "A Terraform type expression such as `map(string)` that the value is converted to.",
+					},
+					{
+						Name:        "prevent_destroy_within",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    true,
+						Computed:    false,
+						Description: "Refuse to destroy the entry until this long after its value was captured, e.g. \"720h\".",
+					},
+					{
+						Name:        "result",
+						Type:        tftypes.DynamicPseudoType,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The cached value converted to `type_constraint`, with the paths in `frozen_paths` taken from the cache. Only set when either is set.",
+					},
+				},
+			},
+		},
+		"cache_command": {
+			Version: 0,
+			Block: &tfprotov5.SchemaBlock{
+				BlockTypes: []*tfprotov5.SchemaNestedBlock{},
+				Attributes: []*tfprotov5.SchemaAttribute{
+					{
+						Name:        "command",
+						Type:        tftypes.List{ElementType: tftypes.String},
+						Required:    true,
+						Optional:    false,
+						Computed:    false,
+						Description: "The program to run followed by its arguments.",
+					},
+					{
+						Name:        "environment",
+						Type:        tftypes.Map{ElementType: tftypes.String},
+						Required:    false,
+						Optional:    true,
+						Computed:    false,
+						Description: "Environment variables to set in addition to those of the provider process.",
+					},
+					{
+						Name:        "working_dir",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    true,
+						Computed:    false,
+						Description: "The directory to run the command in. Defaults to the current directory.",
+					},
+					{
+						Name:        "timeout",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    true,
+						Computed:    false,
+						Description: "How long the command may run, e.g. \"30s\". Defaults to no timeout.",
+					},
+					{
+						Name:        "allowed_exit_codes",
+						Type:        tftypes.List{ElementType: tftypes.Number},
+						Required:    false,
+						Optional:    true,
+						Computed:    false,
+						Description: "Non-zero exit codes that are cached instead of failing the apply.",
+					},
+					{
+						Name:        "stdout",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The standard output of the command",
+					},
+					{
+						Name:        "stderr",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The standard error of the command",
+					},
+					{
+						Name:        "exit_code",
+						Type:        tftypes.Number,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The exit code of the command",
+					},
+					{
+						Name:        "timestamp",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The timestamp the command ran",
+					},
+				},
+			},
+		},
+		"cache_env": {
+			Version: 0,
+			Block: &tfprotov5.SchemaBlock{
+				BlockTypes: []*tfprotov5.SchemaNestedBlock{
+					{
+						TypeName: "variable",
+						Nesting:  tfprotov5.SchemaNestedBlockNestingModeList,
+						MinItems: 1,
+						Block: &tfprotov5.SchemaBlock{
+							Attributes: []*tfprotov5.SchemaAttribute{
+								{
+									Name:        "name",
+									Type:        tftypes.String,
+									Required:    true,
+									Optional:    false,
+									Computed:    false,
+									Description: "The name of the environment variable.",
+								},
+								{
+									Name:        "required",
+									Type:        tftypes.Bool,
+									Required:    false,
+									Optional:    true,
+									Computed:    false,
+									Description: "Whether creating the resource fails when the variable is not set.",
+								},
+								{
+									Name:        "default",
+									Type:        tftypes.String,
+									Required:    false,
+									Optional:    true,
+									Computed:    false,
+									Description: "The value to capture when the variable is not set.",
+								},
+								{
+									Name:        "sensitive",
+									Type:        tftypes.Bool,
+									Required:    false,
+									Optional:    true,
+									Computed:    false,
+									Description: "Whether the value is captured into `sensitive_values` instead of `values`.",
+								},
+							},
+						},
+					},
+				},
+				Attributes: []*tfprotov5.SchemaAttribute{
+					{
+						Name:        "values",
+						Type:        tftypes.Map{ElementType: tftypes.String},
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The captured values of the variables not marked sensitive",
+					},
+					{
+						Name:        "sensitive_values",
+						Type:        tftypes.Map{ElementType: tftypes.String},
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Sensitive:   true,
+						Description: "The captured values of the variables marked sensitive",
+					},
+					{
+						Name:        "timestamp",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The timestamp the environment was captured",
+					},
+				},
+			},
+		},
+		"cache_file": {
+			Version: 0,
+			Block: &tfprotov5.SchemaBlock{
+				BlockTypes: []*tfprotov5.SchemaNestedBlock{},
+				Attributes: []*tfprotov5.SchemaAttribute{
+					{
+						Name:        "path",
+						Type:        tftypes.String,
+						Required:    true,
+						Optional:    false,
+						Computed:    false,
+						Description: "The path of the local file to snapshot.",
+					},
+					{
+						Name:        "encoding",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    true,
+						Computed:    true,
+						Description: "How the content is stored: `text` or `base64`. Defaults to `text`.",
+					},
+					{
+						Name:        "triggers",
+						Type:        tftypes.Map{ElementType: tftypes.String},
+						Required:    false,
+						Optional:    true,
+						Computed:    false,
+						Description: "Arbitrary values that re-capture the file when changed.",
+					},
+					{
+						Name:        "content",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The content of the file, encoded as configured by `encoding`",
+					},
+					{
+						Name:        "size",
+						Type:        tftypes.Number,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The size of the file in bytes",
+					},
+					{
+						Name:        "mode",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The permission bits of the file in octal notation",
+					},
+					{
+						Name:        "sha256",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The hex encoded SHA-256 of the file content",
+					},
+					{
+						Name:        "timestamp",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The timestamp the file was captured",
+					},
+				},
+			},
+		},
+		"cache_version_pin": {
+			Version: 0,
+			Block: &tfprotov5.SchemaBlock{
+				BlockTypes: []*tfprotov5.SchemaNestedBlock{},
+				Attributes: []*tfprotov5.SchemaAttribute{
+					{
+						Name:        "version",
+						Type:        tftypes.String,
+						Required:    true,
+						Optional:    false,
+						Computed:    false,
+						Description: "The semantic version currently offered by the configuration.",
+					},
+					{
+						Name:        "policy",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    true,
+						Computed:    false,
+						Description: "Which upgrades of the pinned version are accepted automatically: `patch`, `minor` or `none`. Defaults to `none`.",
+					},
+					{
+						Name:        "pinned_version",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The version that is pinned.",
+					},
+					{
+						Name:        "timestamp",
+						Type:        tftypes.String,
+						Required:    false,
+						Optional:    false,
+						Computed:    true,
+						Description: "The timestamp the pinned version was last changed",
+					},
 				},
 			},
 		},
@@ -2,11 +2,23 @@ package cache
 
 import (
 	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 )
 
 // GetProviderConfigSchema contains the definitions of all configuration attributes
 func GetProviderConfigSchema() *tfprotov5.Schema {
-	b := tfprotov5.SchemaBlock{}
+	b := tfprotov5.SchemaBlock{
+		Attributes: []*tfprotov5.SchemaAttribute{
+			{
+				Name:        "captured_by_env",
+				Type:        tftypes.List{ElementType: tftypes.String},
+				Required:    false,
+				Optional:    true,
+				Computed:    false,
+				Description: "Environment variables, such as `CI_JOB_ID`, to record in the `captured_by` attribute of a cache_store when it captures a value.",
+			},
+		},
+	}
 
 	return &tfprotov5.Schema{
 		Version: 0,
@@ -17,6 +17,13 @@ func (s *RawProviderServer) ReadResource(ctx context.Context, req *tfprotov5.Rea
 		return resp, nil
 	}
 
+	ctx, cancel := s.stopContext(ctx)
+	defer cancel()
+	if diags := stoppedDiagnostic(ctx); len(diags) > 0 {
+		resp.Diagnostics = append(resp.Diagnostics, diags...)
+		return resp, nil
+	}
+
 	var resState map[string]tftypes.Value
 	var err error
 	rt, err := GetResourceType(req.TypeName)
@@ -56,8 +63,9 @@ func (s *RawProviderServer) ReadResource(ctx context.Context, req *tfprotov5.Rea
 		return resp, nil
 	}
 
+	// Only a cache_store must hold a value, unless it is still waiting to capture one or holds it in 'result'.
 	co, hasOb := resState["value"]
-	if !hasOb || co.IsNull() {
+	if req.TypeName == "cache_store" && (!hasOb || (co.IsNull() && !isPending(resState) && resState["result"].IsNull())) {
 		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
 			Severity: tfprotov5.DiagnosticSeverityError,
 			Summary:  "Current state of resource has no 'value' attribute",
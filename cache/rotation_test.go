@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestPlanStoreRotationWindow(t *testing.T) {
+	captured := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[string]struct {
+		now     time.Time
+		replace bool
+	}{
+		"before the boundary":       {now: captured.Add(6 * time.Hour)},
+		"within the window":         {now: time.Date(2024, 1, 2, 0, 30, 0, 0, time.UTC), replace: true},
+		"at the end of the window":  {now: time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC), replace: true},
+		"after the window":          {now: time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC)},
+		"within a later window":     {now: time.Date(2024, 1, 5, 0, 10, 0, 0, time.UTC), replace: true},
+		"between two later windows": {now: time.Date(2024, 1, 5, 12, 0, 0, 0, time.UTC)},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := newTestHarness(t, "cache_store")
+			config := h.config(map[string]tftypes.Value{
+				"value":           tftypes.NewValue(tftypes.String, "ami-1"),
+				"rotate_on":       tftypes.NewValue(tftypes.String, "@daily"),
+				"rotation_window": tftypes.NewValue(tftypes.String, "1h"),
+			})
+			h.s.clock = func() time.Time { return captured }
+			assertNoDiagnostics(t, h.step(config))
+			h.s.clock = func() time.Time { return c.now }
+
+			config["value"] = tftypes.NewValue(tftypes.String, "ami-2")
+			resp, err := h.s.PlanResourceChange(context.Background(), &tfprotov5.PlanResourceChangeRequest{
+				TypeName:         "cache_store",
+				PriorState:       encodeState(t, "cache_store", h.state),
+				ProposedNewState: encodeState(t, "cache_store", h.proposedNewState(config)),
+				Config:           encodeState(t, "cache_store", config),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hasErrors(resp.Diagnostics) {
+				t.Fatalf("unexpected errors: %s", diagnosticSummaries(resp.Diagnostics))
+			}
+
+			planned := decodeState(t, "cache_store", resp.PlannedState)
+			want := tftypes.NewValue(tftypes.String, "ami-1")
+			if c.replace {
+				want = config["value"]
+			}
+			if !planned["value"].Equal(want) {
+				t.Errorf("expected the planned value %s, got %s", want, planned["value"])
+			}
+			if replaced := len(resp.RequiresReplace) > 0; replaced != c.replace {
+				t.Errorf("expected replacement to be %t, got %v", c.replace, resp.RequiresReplace)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package cache
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// valueRule is a 'validation' block of a cache_store.
+type valueRule struct {
+	index        int
+	path         []string
+	regex        *regexp.Regexp
+	min, max     *big.Float
+	requiredKeys []string
+	minLength    *big.Float
+	maxLength    *big.Float
+	message      string
+}
+
+// valueRules decodes the 'validation' blocks, returning diagnostics for rules that cannot be used.
+// It reports false while any of them is not known yet.
+func valueRules(blocks tftypes.Value) ([]valueRule, []*tfprotov5.Diagnostic, bool) {
+	if !blocks.IsFullyKnown() {
+		return nil, nil, false
+	}
+	var list []tftypes.Value
+	_ = blocks.As(&list)
+
+	var diags []*tfprotov5.Diagnostic
+	rules := make([]valueRule, 0, len(list))
+	for i, b := range list {
+		blockPath := tftypes.NewAttributePath().WithAttributeName("validation").WithElementKeyInt(i)
+		attrs := map[string]tftypes.Value{}
+		_ = b.As(&attrs)
+
+		r := valueRule{index: i}
+		var path, regex *string
+		_ = attrs["path"].As(&path)
+		_ = attrs["regex"].As(&regex)
+		_ = attrs["min"].As(&r.min)
+		_ = attrs["max"].As(&r.max)
+		_ = attrs["min_length"].As(&r.minLength)
+		_ = attrs["max_length"].As(&r.maxLength)
+		_ = attrs["error_message"].As(&r.message)
+		var keys []tftypes.Value
+		_ = attrs["required_keys"].As(&keys)
+		for _, k := range keys {
+			var key string
+			_ = k.As(&key)
+			r.requiredKeys = append(r.requiredKeys, key)
+		}
+
+		if path != nil {
+			r.path = strings.Split(*path, ".")
+			for _, s := range r.path {
+				if s == "" {
+					diags = append(diags, &tfprotov5.Diagnostic{
+						Severity:  tfprotov5.DiagnosticSeverityError,
+						Summary:   "Invalid validation path",
+						Detail:    fmt.Sprintf("%q is not a valid path, segments must not be empty.", *path),
+						Attribute: blockPath.WithAttributeName("path"),
+					})
+					break
+				}
+			}
+		}
+		if regex != nil {
+			re, err := regexp.Compile(*regex)
+			if err != nil {
+				diags = append(diags, &tfprotov5.Diagnostic{
+					Severity:  tfprotov5.DiagnosticSeverityError,
+					Summary:   "Invalid regular expression",
+					Detail:    err.Error(),
+					Attribute: blockPath.WithAttributeName("regex"),
+				})
+			}
+			r.regex = re
+		}
+		if r.min != nil && r.max != nil && r.min.Cmp(r.max) > 0 {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityError,
+				Summary:   "Invalid validation range",
+				Detail:    "min must not be greater than max.",
+				Attribute: blockPath.WithAttributeName("min"),
+			})
+		}
+		if r.minLength != nil && r.maxLength != nil && r.minLength.Cmp(r.maxLength) > 0 {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityError,
+				Summary:   "Invalid validation range",
+				Detail:    "min_length must not be greater than max_length.",
+				Attribute: blockPath.WithAttributeName("min_length"),
+			})
+		}
+		if regex == nil && r.min == nil && r.max == nil && len(keys) == 0 && r.minLength == nil && r.maxLength == nil {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityError,
+				Summary:   "Empty validation rule",
+				Detail:    "Set at least one of regex, min, max, required_keys, min_length or max_length.",
+				Attribute: blockPath,
+			})
+		}
+		rules = append(rules, r)
+	}
+	return rules, diags, true
+}
+
+// checkValueRules returns an error diagnostic for every rule the known parts of v do not satisfy.
+// A null value satisfies all rules.
+func checkValueRules(rules []valueRule, v tftypes.Value) (diags []*tfprotov5.Diagnostic) {
+	if v.IsNull() {
+		return
+	}
+	for _, r := range rules {
+		target, path, found := lookupValuePath(v, r.path)
+		if !found {
+			diags = append(diags, r.failure(path, fmt.Sprintf("The value has no %q.", strings.Join(r.path, "."))))
+			continue
+		}
+		if !target.IsKnown() || target.IsNull() {
+			continue
+		}
+		if msg := r.check(target); msg != "" {
+			diags = append(diags, r.failure(path, msg))
+		}
+	}
+	return
+}
+
+// check returns why v does not satisfy the rule, or an empty string if it does.
+func (r valueRule) check(v tftypes.Value) string {
+	typ := v.Type()
+	if r.regex != nil {
+		if !typ.Is(tftypes.String) {
+			return fmt.Sprintf("regex requires a string, got %s.", typeName(typ))
+		}
+		var s string
+		_ = v.As(&s)
+		if !r.regex.MatchString(s) {
+			return fmt.Sprintf("%q does not match %q.", s, r.regex.String())
+		}
+	}
+	if r.min != nil || r.max != nil {
+		if !typ.Is(tftypes.Number) {
+			return fmt.Sprintf("min and max require a number, got %s.", typeName(typ))
+		}
+		n := new(big.Float)
+		_ = v.As(&n)
+		if r.min != nil && n.Cmp(r.min) < 0 {
+			return fmt.Sprintf("%s is less than the minimum of %s.", n.Text('f', -1), r.min.Text('f', -1))
+		}
+		if r.max != nil && n.Cmp(r.max) > 0 {
+			return fmt.Sprintf("%s is greater than the maximum of %s.", n.Text('f', -1), r.max.Text('f', -1))
+		}
+	}
+	if len(r.requiredKeys) > 0 {
+		if !typ.Is(tftypes.Object{}) && !typ.Is(tftypes.Map{}) {
+			return fmt.Sprintf("required_keys requires an object or map, got %s.", typeName(typ))
+		}
+		elems := map[string]tftypes.Value{}
+		_ = v.As(&elems)
+		var missing []string
+		for _, k := range r.requiredKeys {
+			if e, ok := elems[k]; !ok || e.IsNull() {
+				missing = append(missing, strconv.Quote(k))
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Sprintf("Missing required keys: %s.", strings.Join(missing, ", "))
+		}
+	}
+	if r.minLength != nil || r.maxLength != nil {
+		var length int
+		switch {
+		case typ.Is(tftypes.List{}), typ.Is(tftypes.Set{}), typ.Is(tftypes.Tuple{}):
+			var elems []tftypes.Value
+			_ = v.As(&elems)
+			length = len(elems)
+		case typ.Is(tftypes.Map{}):
+			elems := map[string]tftypes.Value{}
+			_ = v.As(&elems)
+			length = len(elems)
+		default:
+			return fmt.Sprintf("min_length and max_length require a collection, got %s.", typeName(typ))
+		}
+		l := big.NewFloat(float64(length))
+		if r.minLength != nil && l.Cmp(r.minLength) < 0 {
+			return fmt.Sprintf("%d elements are fewer than the minimum of %s.", length, r.minLength.Text('f', -1))
+		}
+		if r.maxLength != nil && l.Cmp(r.maxLength) > 0 {
+			return fmt.Sprintf("%d elements are more than the maximum of %s.", length, r.maxLength.Text('f', -1))
+		}
+	}
+	return ""
+}
+
+func (r valueRule) failure(path *tftypes.AttributePath, detail string) *tfprotov5.Diagnostic {
+	if r.message != "" {
+		detail = r.message
+	}
+	return &tfprotov5.Diagnostic{
+		Severity:  tfprotov5.DiagnosticSeverityError,
+		Summary:   fmt.Sprintf("Value failed validation rule %d", r.index+1),
+		Detail:    detail,
+		Attribute: withAttributePrefix("value", path),
+	}
+}
+
+// lookupValuePath follows dotted path segments into v. It reports false when part of the path does not exist;
+// an unknown value along the way is returned as is, since what it contains is not known yet.
+func lookupValuePath(v tftypes.Value, segments []string) (tftypes.Value, *tftypes.AttributePath, bool) {
+	path := tftypes.NewAttributePath()
+	for _, s := range segments {
+		if !v.IsKnown() {
+			return v, path, true
+		}
+		if v.IsNull() {
+			return v, path, false
+		}
+		typ := v.Type()
+		switch {
+		case typ.Is(tftypes.Object{}), typ.Is(tftypes.Map{}):
+			elems := map[string]tftypes.Value{}
+			_ = v.As(&elems)
+			e, ok := elems[s]
+			if typ.Is(tftypes.Object{}) {
+				path = path.WithAttributeName(s)
+			} else {
+				path = path.WithElementKeyString(s)
+			}
+			if !ok {
+				return v, path, false
+			}
+			v = e
+		case typ.Is(tftypes.List{}), typ.Is(tftypes.Tuple{}):
+			var elems []tftypes.Value
+			_ = v.As(&elems)
+			i, err := strconv.Atoi(s)
+			if err != nil || i < 0 || i >= len(elems) {
+				return v, path, false
+			}
+			path = path.WithElementKeyInt(i)
+			v = elems[i]
+		default:
+			return v, path, false
+		}
+	}
+	return v, path, true
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func validationBlocks(t *testing.T, rules ...map[string]tftypes.Value) tftypes.Value {
+	t.Helper()
+	rt, err := GetResourceType("cache_store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockType := rt.(tftypes.Object).AttributeTypes["validation"].(tftypes.List)
+	ruleType := blockType.ElementType.(tftypes.Object)
+
+	var blocks []tftypes.Value
+	for _, r := range rules {
+		attrs := map[string]tftypes.Value{}
+		for name, typ := range ruleType.AttributeTypes {
+			attrs[name] = tftypes.NewValue(typ, nil)
+		}
+		for name, v := range r {
+			attrs[name] = v
+		}
+		blocks = append(blocks, tftypes.NewValue(ruleType, attrs))
+	}
+	return tftypes.NewValue(blockType, blocks)
+}
+
+func TestCheckValueRules(t *testing.T) {
+	value := tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"image_id": tftypes.String,
+		"amis":     tftypes.List{ElementType: tftypes.String},
+		"count":    tftypes.Number,
+	}}, map[string]tftypes.Value{
+		"image_id": tftypes.NewValue(tftypes.String, "ami-123"),
+		"amis":     tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{}),
+		"count":    tftypes.NewValue(tftypes.Number, 3),
+	})
+	str := func(s string) tftypes.Value { return tftypes.NewValue(tftypes.String, s) }
+	num := func(n int) tftypes.Value { return tftypes.NewValue(tftypes.Number, n) }
+
+	cases := map[string]struct {
+		rule  map[string]tftypes.Value
+		valid bool
+	}{
+		"regex":                {map[string]tftypes.Value{"path": str("image_id"), "regex": str("^ami-")}, true},
+		"regex mismatch":       {map[string]tftypes.Value{"path": str("image_id"), "regex": str("^ami-[a-f0-9]{8}$")}, false},
+		"regex on number":      {map[string]tftypes.Value{"path": str("count"), "regex": str(".")}, false},
+		"range":                {map[string]tftypes.Value{"path": str("count"), "min": num(1), "max": num(3)}, true},
+		"above max":            {map[string]tftypes.Value{"path": str("count"), "max": num(2)}, false},
+		"required keys":        {map[string]tftypes.Value{"required_keys": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{str("image_id")})}, true},
+		"missing required key": {map[string]tftypes.Value{"required_keys": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{str("vpc_id")})}, false},
+		"empty list":           {map[string]tftypes.Value{"path": str("amis"), "min_length": num(1)}, false},
+		"missing path":         {map[string]tftypes.Value{"path": str("subnet"), "regex": str(".")}, false},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			rules, diags, ok := valueRules(validationBlocks(t, c.rule))
+			if !ok || len(diags) > 0 {
+				t.Fatalf("rule did not decode: %v", diags)
+			}
+			diags = checkValueRules(rules, value)
+			if c.valid && len(diags) > 0 {
+				t.Errorf("unexpected failure: %s", diags[0].Detail)
+			}
+			if !c.valid && len(diags) == 0 {
+				t.Error("expected the rule to fail")
+			}
+		})
+	}
+}
+
+func TestPlanStoreRefusesInvalidValue(t *testing.T) {
+	config := map[string]tftypes.Value{
+		"value": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{}),
+		"validation": validationBlocks(t, map[string]tftypes.Value{
+			"min_length":    tftypes.NewValue(tftypes.Number, 1),
+			"error_message": tftypes.NewValue(tftypes.String, "No AMI found."),
+		}),
+	}
+	resp := planStoreChangeAllowingErrors(t, nil, config)
+	if len(resp.Diagnostics) != 1 || resp.Diagnostics[0].Detail != "No AMI found." {
+		t.Fatalf("expected the validation rule to fail, got %v", resp.Diagnostics)
+	}
+}
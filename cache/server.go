@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
@@ -18,12 +19,71 @@ type RawProviderServer struct {
 
 	//providerEnabled bool
 	hostTFVersion string
+	capturedByEnv []string
+
+	// stopCtx is cancelled when Terraform stops the provider, e.g. on Ctrl-C.
+	stopCtx    context.Context
+	stopCancel context.CancelFunc
+
+	// clock returns the current time when planning; tests set it to plan at a fixed time.
+	clock func() time.Time
+}
+
+func newRawProviderServer(logger hclog.Logger) *RawProviderServer {
+	stopCtx, stopCancel := context.WithCancel(context.Background())
+	return &RawProviderServer{
+		logger:     logger,
+		stopCtx:    stopCtx,
+		stopCancel: stopCancel,
+	}
+}
+
+// now returns the time to plan with, which is the current time unless a clock is set.
+func (s *RawProviderServer) now() time.Time {
+	if s.clock != nil {
+		return s.clock()
+	}
+	return time.Now()
+}
+
+// stopContext returns a context that is cancelled with ctx, or when Terraform stops the provider.
+func (s *RawProviderServer) stopContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if s.stopCtx == nil {
+		return ctx, cancel
+	}
+	stop := context.AfterFunc(s.stopCtx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// stoppedDiagnostic returns an error if ctx was cancelled, so that nothing captured by the cancelled operation is stored.
+func stoppedDiagnostic(ctx context.Context) []*tfprotov5.Diagnostic {
+	if ctx.Err() == nil {
+		return nil
+	}
+	return []*tfprotov5.Diagnostic{{
+		Severity: tfprotov5.DiagnosticSeverityError,
+		Summary:  "Operation cancelled",
+		Detail:   "The provider was stopped before the operation completed. Nothing was captured.",
+	}}
 }
 
 func dump(v interface{}) hclog.Format {
 	return hclog.Fmt("%v", v)
 }
 
+func hasErrors(diags []*tfprotov5.Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == tfprotov5.DiagnosticSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
 // PrepareProviderConfig function
 func (s *RawProviderServer) PrepareProviderConfig(ctx context.Context, req *tfprotov5.PrepareProviderConfigRequest) (*tfprotov5.PrepareProviderConfigResponse, error) {
 	s.logger.Trace("[PrepareProviderConfig][Request]\n%s\n", dump(*req))
@@ -38,36 +98,6 @@ func (s *RawProviderServer) ValidateDataSourceConfig(ctx context.Context, req *t
 	return resp, nil
 }
 
-// UpgradeResourceState isn't really useful in this provider, but we have to loop the state back through to keep Terraform happy.
-func (s *RawProviderServer) UpgradeResourceState(ctx context.Context, req *tfprotov5.UpgradeResourceStateRequest) (*tfprotov5.UpgradeResourceStateResponse, error) {
-	resp := &tfprotov5.UpgradeResourceStateResponse{}
-	resp.Diagnostics = []*tfprotov5.Diagnostic{}
-
-	sch := GetProviderResourceSchema()
-	rt := GetObjectTypeFromSchema(sch[req.TypeName])
-
-	rv, err := req.RawState.Unmarshal(rt)
-	if err != nil {
-		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
-			Severity: tfprotov5.DiagnosticSeverityError,
-			Summary:  "Failed to decode old state during upgrade",
-			Detail:   err.Error(),
-		})
-		return resp, nil
-	}
-	us, err := tfprotov5.NewDynamicValue(rt, rv)
-	if err != nil {
-		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
-			Severity: tfprotov5.DiagnosticSeverityError,
-			Summary:  "Failed to encode new state during upgrade",
-			Detail:   err.Error(),
-		})
-	}
-	resp.UpgradedState = &us
-
-	return resp, nil
-}
-
 // ReadDataSource function
 func (s *RawProviderServer) ReadDataSource(ctx context.Context, req *tfprotov5.ReadDataSourceRequest) (*tfprotov5.ReadDataSourceResponse, error) {
 	s.logger.Trace("[ReadDataSource][Request]\n%s\n", dump(*req))
@@ -79,5 +109,49 @@ func (s *RawProviderServer) ReadDataSource(ctx context.Context, req *tfprotov5.R
 func (s *RawProviderServer) StopProvider(ctx context.Context, req *tfprotov5.StopProviderRequest) (*tfprotov5.StopProviderResponse, error) {
 	s.logger.Trace("[StopProvider][Request]\n%s\n", dump(*req))
 
-	return nil, status.Errorf(codes.Unimplemented, "method Stop not implemented")
+	if s.stopCancel != nil {
+		s.stopCancel()
+	}
+	return &tfprotov5.StopProviderResponse{}, nil
+}
+
+// UpgradeResourceIdentity function
+func (s *RawProviderServer) UpgradeResourceIdentity(ctx context.Context, req *tfprotov5.UpgradeResourceIdentityRequest) (*tfprotov5.UpgradeResourceIdentityResponse, error) {
+	s.logger.Trace("[UpgradeResourceIdentity][Request]\n%s\n", dump(*req))
+
+	return nil, status.Errorf(codes.Unimplemented, "method UpgradeResourceIdentity not implemented")
+}
+
+// GetFunctions function
+func (s *RawProviderServer) GetFunctions(ctx context.Context, req *tfprotov5.GetFunctionsRequest) (*tfprotov5.GetFunctionsResponse, error) {
+	return &tfprotov5.GetFunctionsResponse{
+		Functions: map[string]*tfprotov5.Function{},
+	}, nil
+}
+
+// CallFunction function
+func (s *RawProviderServer) CallFunction(ctx context.Context, req *tfprotov5.CallFunctionRequest) (*tfprotov5.CallFunctionResponse, error) {
+	s.logger.Trace("[CallFunction][Request]\n%s\n", dump(*req))
+
+	return nil, status.Errorf(codes.Unimplemented, "method CallFunction not implemented")
+}
+
+// ValidateEphemeralResourceConfig function
+func (s *RawProviderServer) ValidateEphemeralResourceConfig(ctx context.Context, req *tfprotov5.ValidateEphemeralResourceConfigRequest) (*tfprotov5.ValidateEphemeralResourceConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateEphemeralResourceConfig not implemented")
+}
+
+// OpenEphemeralResource function
+func (s *RawProviderServer) OpenEphemeralResource(ctx context.Context, req *tfprotov5.OpenEphemeralResourceRequest) (*tfprotov5.OpenEphemeralResourceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OpenEphemeralResource not implemented")
+}
+
+// RenewEphemeralResource function
+func (s *RawProviderServer) RenewEphemeralResource(ctx context.Context, req *tfprotov5.RenewEphemeralResourceRequest) (*tfprotov5.RenewEphemeralResourceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenewEphemeralResource not implemented")
+}
+
+// CloseEphemeralResource function
+func (s *RawProviderServer) CloseEphemeralResource(ctx context.Context, req *tfprotov5.CloseEphemeralResourceRequest) (*tfprotov5.CloseEphemeralResourceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseEphemeralResource not implemented")
 }
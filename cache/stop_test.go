@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestStopProviderCancelsApply(t *testing.T) {
+	s := newRawProviderServer(hclog.NewNullLogger())
+	planned := map[string]tftypes.Value{
+		"command": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+			tftypes.NewValue(tftypes.String, "sleep"),
+			tftypes.NewValue(tftypes.String, "30"),
+		}),
+	}
+	for name, typ := range commandCapturedAttributes {
+		planned[name] = tftypes.NewValue(typ, tftypes.UnknownValue)
+	}
+
+	req := &tfprotov5.ApplyResourceChangeRequest{
+		TypeName:     "cache_command",
+		PriorState:   encodeState(t, "cache_command", nil),
+		PlannedState: encodeState(t, "cache_command", planned),
+	}
+
+	done := make(chan *tfprotov5.ApplyResourceChangeResponse)
+	go func() {
+		resp, err := s.ApplyResourceChange(context.Background(), req)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- resp
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	if _, err := s.StopProvider(context.Background(), &tfprotov5.StopProviderRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case resp := <-done:
+		if !hasErrors(resp.Diagnostics) {
+			t.Error("a cancelled apply should fail")
+		}
+		if resp.NewState != nil {
+			t.Error("a cancelled apply should not store any state")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("apply was not cancelled")
+	}
+}
@@ -0,0 +1,596 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+const (
+	captureAlways          = "always"
+	captureKnownAndNotNull = "known_and_not_null"
+)
+
+func validateStore(configVal map[string]tftypes.Value) (diags []*tfprotov5.Diagnostic) {
+	if v := configVal["capture_when"]; v.IsKnown() && !v.IsNull() {
+		var when string
+		_ = v.As(&when)
+		if when != captureAlways && when != captureKnownAndNotNull {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityError,
+				Summary:   "Invalid capture condition",
+				Detail:    fmt.Sprintf("capture_when must be %q or %q, got %q.", captureAlways, captureKnownAndNotNull, when),
+				Attribute: tftypes.NewAttributePath().WithAttributeName("capture_when"),
+			})
+		}
+	}
+	if _, _, err := parseValuePaths(configVal["frozen_paths"]); err != nil {
+		diags = append(diags, &tfprotov5.Diagnostic{
+			Severity:  tfprotov5.DiagnosticSeverityError,
+			Summary:   "Invalid frozen path",
+			Detail:    err.Error(),
+			Attribute: tftypes.NewAttributePath().WithAttributeName("frozen_paths"),
+		})
+	}
+	if _, _, err := parseValuePaths(configVal["ignore_paths"]); err != nil {
+		diags = append(diags, &tfprotov5.Diagnostic{
+			Severity:  tfprotov5.DiagnosticSeverityError,
+			Summary:   "Invalid ignored path",
+			Detail:    err.Error(),
+			Attribute: tftypes.NewAttributePath().WithAttributeName("ignore_paths"),
+		})
+	}
+	if v := configVal["type_constraint"]; v.IsKnown() && !v.IsNull() {
+		var expr string
+		_ = v.As(&expr)
+		if _, err := parseTypeConstraint(expr); err != nil {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityError,
+				Summary:   "Invalid type constraint",
+				Detail:    fmt.Sprintf("%q is not a valid type expression: %s", expr, err),
+				Attribute: tftypes.NewAttributePath().WithAttributeName("type_constraint"),
+			})
+		} else if _, diag := constrainValue(configVal, configVal["value"]); diag != nil {
+			diags = append(diags, diag)
+		}
+	}
+	if rules, ruleDiags, ok := valueRules(configVal["validation"]); ok {
+		diags = append(diags, ruleDiags...)
+		if len(ruleDiags) == 0 {
+			value := configVal["value"]
+			if v, diag := constrainValue(configVal, value); diag == nil {
+				value = v
+			}
+			diags = append(diags, checkValueRules(rules, value)...)
+		}
+	}
+	if v := configVal["timestamp_format"]; v.IsKnown() && !v.IsNull() {
+		var format string
+		_ = v.As(&format)
+		if _, err := formatTimestamp(time.Now(), format); err != nil {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityError,
+				Summary:   "Invalid timestamp format",
+				Detail:    err.Error() + ".",
+				Attribute: tftypes.NewAttributePath().WithAttributeName("timestamp_format"),
+			})
+		}
+	}
+	if v := configVal["rotate_on"]; v.IsKnown() && !v.IsNull() {
+		var expr string
+		_ = v.As(&expr)
+		if _, err := parseCron(expr); err != nil {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityError,
+				Summary:   "Invalid rotation schedule",
+				Detail:    fmt.Sprintf("%q is not a valid cron expression: %s", expr, err),
+				Attribute: tftypes.NewAttributePath().WithAttributeName("rotate_on"),
+			})
+		}
+	}
+	if v := configVal["rotation_window"]; v.IsKnown() && !v.IsNull() {
+		var window string
+		_ = v.As(&window)
+		if d, err := time.ParseDuration(window); err != nil || d <= 0 {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityError,
+				Summary:   "Invalid rotation window",
+				Detail:    fmt.Sprintf("%q is not a positive duration such as \"4h\" or \"30m\".", window),
+				Attribute: tftypes.NewAttributePath().WithAttributeName("rotation_window"),
+			})
+		}
+	}
+	if v := configVal["prevent_destroy_within"]; v.IsKnown() && !v.IsNull() {
+		var within string
+		_ = v.As(&within)
+		if d, err := time.ParseDuration(within); err != nil || d <= 0 {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityError,
+				Summary:   "Invalid destroy protection",
+				Detail:    fmt.Sprintf("%q is not a positive duration such as \"720h\" or \"30m\".", within),
+				Attribute: tftypes.NewAttributePath().WithAttributeName("prevent_destroy_within"),
+			})
+		}
+	}
+	return
+}
+
+// planStoreDestroy refuses to destroy an entry that captured its value less than 'prevent_destroy_within' ago.
+// The protection comes from the prior state, so removing the resource from the configuration does not lift it.
+func planStoreDestroy(priorVal map[string]tftypes.Value, now time.Time, resp *tfprotov5.PlanResourceChangeResponse) {
+	v := priorVal["prevent_destroy_within"]
+	if !v.IsKnown() || v.IsNull() || isPending(priorVal) {
+		return
+	}
+	var within string
+	_ = v.As(&within)
+	d, err := time.ParseDuration(within)
+	if err != nil || d <= 0 {
+		return
+	}
+	created, ok := capturedAt(priorVal)
+	if !ok {
+		return
+	}
+	if until := created.Add(d); now.Before(until) {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  "Cached value is protected from destroy",
+			Detail: fmt.Sprintf("The value was captured at %s and cannot be destroyed within %s of that, until %s. "+
+				"Lower prevent_destroy_within and apply to destroy it sooner.",
+				created.UTC().Format(time.RFC3339), within, until.UTC().Format(time.RFC3339)),
+			Attribute: tftypes.NewAttributePath().WithAttributeName("prevent_destroy_within"),
+		})
+	}
+}
+
+// storeFrozenPaths returns the paths listed in 'frozen_paths', and whether only those paths are frozen rather than the whole value.
+// The paths are nil while they are not known yet.
+func storeFrozenPaths(vals map[string]tftypes.Value) (valuePaths, bool) {
+	list, ok := vals["frozen_paths"]
+	if !ok || list.IsNull() {
+		return nil, false
+	}
+	paths, _, err := parseValuePaths(list)
+	if err != nil {
+		return nil, true
+	}
+	return paths, true
+}
+
+// storeFingerprint returns the fingerprint of v, leaving out the paths listed in 'ignore_paths'.
+func storeFingerprint(vals map[string]tftypes.Value, v tftypes.Value) tftypes.Value {
+	ignored, known, err := parseValuePaths(vals["ignore_paths"])
+	if !known || err != nil {
+		return tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+	}
+	return fingerprintOrUnknown(maskPaths(v, ignored))
+}
+
+// storeTypeConstraint returns the type parsed from 'type_constraint', or nil when there is none.
+// It reports false while the constraint is not known yet, or does not parse.
+func storeTypeConstraint(vals map[string]tftypes.Value) (tftypes.Type, bool) {
+	v := vals["type_constraint"]
+	if v.IsNull() {
+		return nil, true
+	}
+	if !v.IsKnown() {
+		return nil, false
+	}
+	var expr string
+	_ = v.As(&expr)
+	typ, err := parseTypeConstraint(expr)
+	if err != nil {
+		return nil, false
+	}
+	return typ, true
+}
+
+// constrainValue converts v to the 'type_constraint' of a cache_store, or returns a diagnostic pointing at the part of it that does not conform.
+// The result is unknown while the constraint is not known yet.
+func constrainValue(vals map[string]tftypes.Value, v tftypes.Value) (tftypes.Value, *tfprotov5.Diagnostic) {
+	typ, ok := storeTypeConstraint(vals)
+	if !ok {
+		return tftypes.NewValue(tftypes.DynamicPseudoType, tftypes.UnknownValue), nil
+	}
+	if typ == nil {
+		return v, nil
+	}
+	converted, err := convertValue(tftypes.NewAttributePath().WithAttributeName("value"), v, typ)
+	if err != nil {
+		var expr string
+		_ = vals["type_constraint"].As(&expr)
+		path, msg := conversionErrorPath(err)
+		return tftypes.Value{}, &tfprotov5.Diagnostic{
+			Severity:  tfprotov5.DiagnosticSeverityError,
+			Summary:   "Value does not match type constraint",
+			Detail:    fmt.Sprintf("The value cannot be converted to %s: %s.", expr, msg),
+			Attribute: path,
+		}
+	}
+	return converted, nil
+}
+
+// planStore plans a cache_store, refusing to capture a value that fails its 'validation' rules,
+// since an invalid value would persist once captured.
+func (s *RawProviderServer) planStore(priorState tftypes.Value, priorVal, proposedVal map[string]tftypes.Value, resp *tfprotov5.PlanResourceChangeResponse) {
+	s.planStoreValue(priorState, priorVal, proposedVal, resp)
+	if hasErrors(resp.Diagnostics) {
+		return
+	}
+	planStoreMetadata(priorVal, proposedVal)
+
+	storedAttr := "value"
+	if !proposedVal["result"].IsNull() {
+		storedAttr = "result"
+	}
+	if !priorState.IsNull() && proposedVal[storedAttr].Equal(priorVal[storedAttr]) {
+		return
+	}
+	if rules, _, ok := valueRules(proposedVal["validation"]); ok {
+		resp.Diagnostics = append(resp.Diagnostics, checkValueRules(rules, proposedVal[storedAttr])...)
+	}
+}
+
+// planStoreValue freezes the cached value of a cache_store, or only the parts of it listed in 'frozen_paths'.
+// The configured value only replaces the cached one once its fingerprint has been approved,
+// or when the plan runs inside a rotation window.
+func (s *RawProviderServer) planStoreValue(priorState tftypes.Value, priorVal, proposedVal map[string]tftypes.Value, resp *tfprotov5.PlanResourceChangeResponse) {
+	valuePath := tftypes.NewAttributePath().WithAttributeName("value")
+	frozen, partial := storeFrozenPaths(proposedVal)
+	constrained := !proposedVal["type_constraint"].IsNull()
+
+	configured := proposedVal["value"]
+	pending, diag := constrainValue(proposedVal, configured)
+	if diag != nil {
+		resp.Diagnostics = append(resp.Diagnostics, diag)
+		return
+	}
+
+	if priorState.IsNull() || isPending(priorVal) {
+		// plan for Create, or for an entry still waiting for a value it may capture
+		proposedVal["captured"] = plannedCaptured(proposedVal)
+		stored := configured
+		proposedVal["result"] = tftypes.NewValue(tftypes.DynamicPseudoType, nil)
+		if partial || constrained {
+			proposedVal["result"] = pending
+			stored = pending
+		}
+		if priorState.IsNull() || !configured.Equal(priorVal["value"]) || !proposedVal["result"].Equal(priorVal["result"]) {
+			proposedVal["timestamp"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+			proposedVal["fingerprint"] = storeFingerprint(proposedVal, stored)
+		}
+		if priorState.IsNull() || !proposedVal["rotate_on"].Equal(priorVal["rotate_on"]) {
+			proposedVal["next_rotation"] = tftypes.NewValue(tftypes.String, nil)
+			if !proposedVal["rotate_on"].IsNull() {
+				proposedVal["next_rotation"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+			}
+		}
+		return
+	}
+
+	// plan for Update
+	// The cached value stays frozen, only the arguments governing it follow the configuration.
+	// With 'frozen_paths', 'value' follows the configuration as well, and 'result' holds it with the frozen paths taken from the cache.
+	// With 'type_constraint', 'result' holds the value converted to that type.
+	// Whenever 'result' is set it holds the cached value, including one held there after the configured value became null.
+	cached := priorVal["value"]
+	cachedAttr := "value"
+	if partial {
+		cachedAttr = "result"
+	}
+	if !priorVal["result"].IsNull() {
+		cached = priorVal["result"]
+	}
+	cached, diag = constrainValue(proposedVal, cached)
+	if diag != nil {
+		diag.Summary = "Cached value does not match type constraint"
+		diag.Detail += " Change the type constraint, or replace the resource to capture a new value."
+		resp.Diagnostics = append(resp.Diagnostics, diag)
+		return
+	}
+	// Once 'type_constraint' is removed, the cached value is still the converted one. A configured value
+	// that converts to it is the same value, so it takes the place of the cached one instead of differing in type.
+	if !constrained && !priorVal["type_constraint"].IsNull() && !cached.IsNull() && pending.IsFullyKnown() {
+		if converted, err := convertValue(valuePath, pending, cached.Type()); err == nil && converted.Equal(cached) {
+			cached = pending
+		}
+	}
+
+	if !proposedVal["rotate_on"].Equal(priorVal["rotate_on"]) {
+		proposedVal["next_rotation"] = tftypes.NewValue(tftypes.String, nil)
+		if !proposedVal["rotate_on"].IsNull() {
+			proposedVal["next_rotation"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+		}
+	}
+
+	if configured.IsNull() && !cached.IsNull() {
+		s.planStoreNullValue(proposedVal, cached, resp)
+		return
+	}
+
+	// Differences in 'ignore_paths' are not reported. Until those paths are known, no differences are.
+	ignored, ignoredKnown, _ := parseValuePaths(proposedVal["ignore_paths"])
+	differences := func(kept tftypes.Value) (diffs []*tftypes.AttributePath) {
+		if !ignoredKnown {
+			return nil
+		}
+		for _, p := range valueDifferences(tftypes.NewAttributePath(), kept, pending) {
+			if !ignored.covers(p) {
+				diffs = append(diffs, p)
+			}
+		}
+		return diffs
+	}
+	diffs := differences(cached)
+	if !partial {
+		proposedVal["value"] = priorVal["value"]
+		proposedVal["result"] = tftypes.NewValue(tftypes.DynamicPseudoType, nil)
+		if constrained {
+			proposedVal["result"] = cached
+		}
+		if priorVal["value"].IsNull() || (!constrained && !priorVal["result"].IsNull()) {
+			// The cached value is held in 'result' since the configured value became null. Terraform only accepts the
+			// prior value in place of a non-null configured one, so 'value' follows the configuration, and 'result' keeps
+			// holding the cached value while they differ.
+			proposedVal["value"] = configured
+			if len(diffs) > 0 {
+				proposedVal["result"] = cached
+			}
+		}
+		proposedVal["fingerprint"] = storeFingerprint(proposedVal, cached)
+	} else if frozen == nil {
+		proposedVal["result"] = tftypes.NewValue(tftypes.DynamicPseudoType, tftypes.UnknownValue)
+		proposedVal["fingerprint"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+		return
+	} else {
+		merged, conflicts, err := freezePaths(pending, cached, frozen)
+		if err != nil {
+			resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityError,
+				Summary:   "Failed to apply frozen paths",
+				Detail:    err.Error(),
+				Attribute: tftypes.NewAttributePath().WithAttributeName("frozen_paths"),
+			})
+			return
+		}
+		for _, p := range conflicts {
+			resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityWarning,
+				Summary:   "Frozen path cannot be kept",
+				Detail:    "The path is missing from the cached value or its type changed, so it follows the configured value.",
+				Attribute: withAttributePrefix("value", p),
+			})
+		}
+		proposedVal["result"] = merged
+		proposedVal["fingerprint"] = storeFingerprint(proposedVal, merged)
+		// Only the frozen paths that were kept differ from the configuration.
+		diffs = differences(merged)
+	}
+	if len(diffs) == 0 {
+		return
+	}
+
+	// capture replaces the cached value with the configured one
+	capture := func() {
+		proposedVal["result"] = tftypes.NewValue(tftypes.DynamicPseudoType, nil)
+		if partial || constrained {
+			proposedVal["result"] = pending
+		}
+		if !partial {
+			proposedVal["value"] = configured
+		}
+	}
+
+	var pendingFingerprint, approved string
+	if fp := storeFingerprint(proposedVal, pending); fp.IsKnown() {
+		_ = fp.As(&pendingFingerprint)
+	}
+	if proposedVal["approve_fingerprint"].IsKnown() {
+		_ = proposedVal["approve_fingerprint"].As(&approved)
+	}
+	if pendingFingerprint != "" && approved == pendingFingerprint {
+		capture()
+		proposedVal["fingerprint"] = tftypes.NewValue(tftypes.String, pendingFingerprint)
+		proposedVal["timestamp"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+		return
+	}
+
+	if s.inRotationWindow(priorVal, proposedVal) {
+		// re-capture by replacing the resource, so the new value starts out like any other create
+		capture()
+		resp.RequiresReplace = append(resp.RequiresReplace, tftypes.NewAttributePath().WithAttributeName(cachedAttr))
+		return
+	}
+
+	var strict bool
+	if proposedVal["strict"].IsKnown() {
+		_ = proposedVal["strict"].As(&strict)
+	}
+	if strict {
+		detail := "This cache_store is strict, and the configured value differs from the cached value. Restore the original value or replace the resource."
+		if pendingFingerprint != "" {
+			detail += fmt.Sprintf(" To replace the cached value with the configured one, set approve_fingerprint = %q.", pendingFingerprint)
+		}
+		for _, p := range diffs {
+			resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityError,
+				Summary:   "Cached value cannot be changed",
+				Detail:    detail,
+				Attribute: withAttributePrefix("value", p),
+			})
+		}
+		return
+	}
+
+	if pendingFingerprint != "" {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity:  tfprotov5.DiagnosticSeverityWarning,
+			Summary:   "Configured value differs from cached value",
+			Detail:    fmt.Sprintf("The cached value is kept. The pending value has fingerprint %s; set approve_fingerprint = %q to replace the cached value with it.", pendingFingerprint, pendingFingerprint),
+			Attribute: valuePath,
+		})
+	}
+}
+
+// planStoreNullValue plans an update whose configured value became null, for example because what it was read from
+// no longer exists. A null value never replaces a cached one, but Terraform only accepts the prior value in place of
+// a non-null configured one, so 'value' is planned as null and the cached value is held in 'result' until a value
+// is configured again.
+func (s *RawProviderServer) planStoreNullValue(proposedVal map[string]tftypes.Value, cached tftypes.Value, resp *tfprotov5.PlanResourceChangeResponse) {
+	valuePath := tftypes.NewAttributePath().WithAttributeName("value")
+	var strict bool
+	if proposedVal["strict"].IsKnown() {
+		_ = proposedVal["strict"].As(&strict)
+	}
+	if strict {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity:  tfprotov5.DiagnosticSeverityError,
+			Summary:   "Cached value cannot be changed",
+			Detail:    "This cache_store is strict, and the configured value is null. Restore the original value or replace the resource.",
+			Attribute: valuePath,
+		})
+		return
+	}
+
+	proposedVal["result"] = cached
+	proposedVal["fingerprint"] = storeFingerprint(proposedVal, cached)
+
+	var when string
+	_ = proposedVal["capture_when"].As(&when)
+	if when != captureKnownAndNotNull {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity:  tfprotov5.DiagnosticSeverityWarning,
+			Summary:   "Configured value is null",
+			Detail:    "The cached value is kept in 'result', and 'value' is null until a value is configured again.",
+			Attribute: valuePath,
+		})
+	}
+}
+
+// isPending reports whether a cache_store was created with capture_when = "known_and_not_null" and has not captured a value yet.
+// Entries from before 'captured' existed have always captured their value.
+func isPending(stateVal map[string]tftypes.Value) bool {
+	captured, ok := stateVal["captured"]
+	if !ok || captured.IsNull() || !captured.IsKnown() {
+		return false
+	}
+	var b bool
+	_ = captured.As(&b)
+	return !b
+}
+
+// plannedCaptured returns whether the configured value will be captured, or an unknown value if that is only decided during apply.
+func plannedCaptured(proposedVal map[string]tftypes.Value) tftypes.Value {
+	var when string
+	if proposedVal["capture_when"].IsKnown() {
+		_ = proposedVal["capture_when"].As(&when)
+	} else {
+		return tftypes.NewValue(tftypes.Bool, tftypes.UnknownValue)
+	}
+	if when != captureKnownAndNotNull {
+		return tftypes.NewValue(tftypes.Bool, true)
+	}
+	value := proposedVal["value"]
+	if value.IsNull() {
+		return tftypes.NewValue(tftypes.Bool, false)
+	}
+	if !value.IsFullyKnown() {
+		return tftypes.NewValue(tftypes.Bool, tftypes.UnknownValue)
+	}
+	return tftypes.NewValue(tftypes.Bool, true)
+}
+
+// inRotationWindow reports whether a scheduled rotation boundary has passed since the value was captured,
+// and the current time is still within 'rotation_window' of that boundary.
+// Without a window, any plan after the boundary is in the window.
+func (s *RawProviderServer) inRotationWindow(priorVal, proposedVal map[string]tftypes.Value) bool {
+	rotateOn, window := proposedVal["rotate_on"], proposedVal["rotation_window"]
+	if rotateOn.IsNull() || !rotateOn.IsKnown() || !window.IsKnown() {
+		return false
+	}
+	var expr, windowStr string
+	_ = rotateOn.As(&expr)
+	_ = window.As(&windowStr)
+	sched, err := parseCron(expr)
+	if err != nil {
+		return false
+	}
+	captured, ok := capturedAt(priorVal)
+	if !ok {
+		return false
+	}
+
+	now := s.now()
+	from := captured
+	if windowStr != "" {
+		d, err := time.ParseDuration(windowStr)
+		if err != nil {
+			return false
+		}
+		// next is exclusive, so start just before the window opens to include a boundary right at its start
+		if earliest := now.Add(-d - time.Nanosecond); earliest.After(from) {
+			from = earliest
+		}
+	}
+	boundary := sched.next(from)
+	return !boundary.IsZero() && !boundary.After(now)
+}
+
+// applyStore fills in the attributes that are only known once the value is captured.
+func (s *RawProviderServer) applyStore(priorState tftypes.Value, plannedVal, configVal map[string]tftypes.Value, resp *tfprotov5.ApplyResourceChangeResponse) {
+	// Terraform plans again with the final configuration before applying, so the value should be known by now.
+	// Should any part of it still be unknown, the configuration has the final value.
+	if !plannedVal["value"].IsFullyKnown() {
+		if v, ok := configVal["value"]; ok && v.IsFullyKnown() {
+			plannedVal["value"] = v
+		}
+	}
+	cachedAttr := "value"
+	frozen, partial := storeFrozenPaths(plannedVal)
+	if partial || !plannedVal["type_constraint"].IsNull() {
+		cachedAttr = "result"
+		if !plannedVal["result"].IsFullyKnown() {
+			result, diag := constrainValue(plannedVal, plannedVal["value"])
+			if diag != nil {
+				resp.Diagnostics = append(resp.Diagnostics, diag)
+				return
+			}
+			priorVal := make(map[string]tftypes.Value)
+			_ = priorState.As(&priorVal)
+			if partial && !priorState.IsNull() && !isPending(priorVal) {
+				cached := priorVal["value"]
+				if !priorVal["result"].IsNull() {
+					cached = priorVal["result"]
+				}
+				cached, _ = constrainValue(plannedVal, cached)
+				if merged, _, err := freezePaths(result, cached, frozen); err == nil {
+					result = merged
+				}
+			}
+			plannedVal["result"] = result
+		}
+	}
+	if !plannedVal["captured"].IsKnown() {
+		var when string
+		_ = plannedVal["capture_when"].As(&when)
+		plannedVal["captured"] = tftypes.NewValue(tftypes.Bool, when != captureKnownAndNotNull || !plannedVal["value"].IsNull())
+	}
+	if !plannedVal["fingerprint"].IsKnown() {
+		plannedVal["fingerprint"] = storeFingerprint(plannedVal, plannedVal[cachedAttr])
+	}
+	s.applyStoreMetadata(priorState, plannedVal)
+	if !plannedVal["next_rotation"].IsKnown() {
+		plannedVal["next_rotation"] = tftypes.NewValue(tftypes.String, nil)
+		var expr string
+		_ = plannedVal["rotate_on"].As(&expr)
+		captured, ok := capturedAt(plannedVal)
+		if sched, err := parseCron(expr); err == nil && ok {
+			if next := sched.next(captured); !next.IsZero() {
+				plannedVal["next_rotation"] = tftypes.NewValue(tftypes.String, next.Format(time.RFC3339))
+			}
+		}
+	}
+}
@@ -0,0 +1,435 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// parseTypeConstraint parses a Terraform type expression such as "map(string)" or "object({ id = string, ports = list(number) })".
+// "any" becomes tftypes.DynamicPseudoType.
+func parseTypeConstraint(expr string) (tftypes.Type, error) {
+	p := &typeParser{src: expr}
+	typ, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q after the type", p.src[p.pos:])
+	}
+	return typ, nil
+}
+
+type typeParser struct {
+	src string
+	pos int
+}
+
+func (p *typeParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *typeParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *typeParser) ident() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := rune(p.src[p.pos])
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' && c != '-' {
+			break
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// accept consumes c if it is the next non-space character.
+func (p *typeParser) accept(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *typeParser) expect(c byte) error {
+	if !p.accept(c) {
+		if p.pos >= len(p.src) {
+			return p.errorf("expected %q, found the end of the expression", c)
+		}
+		return p.errorf("expected %q, found %q", c, p.src[p.pos])
+	}
+	return nil
+}
+
+func (p *typeParser) parseType() (tftypes.Type, error) {
+	name := p.ident()
+	switch name {
+	case "string":
+		return tftypes.String, nil
+	case "number":
+		return tftypes.Number, nil
+	case "bool":
+		return tftypes.Bool, nil
+	case "any":
+		return tftypes.DynamicPseudoType, nil
+	case "list", "set", "map":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		switch name {
+		case "list":
+			return tftypes.List{ElementType: elem}, nil
+		case "set":
+			return tftypes.Set{ElementType: elem}, nil
+		default:
+			return tftypes.Map{ElementType: elem}, nil
+		}
+	case "tuple":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		if err := p.expect('['); err != nil {
+			return nil, err
+		}
+		var elems []tftypes.Type
+		for !p.accept(']') {
+			elem, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, elem)
+			if !p.accept(',') {
+				if err := p.expect(']'); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return tftypes.Tuple{ElementTypes: elems}, nil
+	case "object":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		if err := p.expect('{'); err != nil {
+			return nil, err
+		}
+		attrs := map[string]tftypes.Type{}
+		for !p.accept('}') {
+			attr := p.ident()
+			if attr == "" {
+				return nil, p.errorf("expected an attribute name")
+			}
+			if _, ok := attrs[attr]; ok {
+				return nil, p.errorf("duplicate attribute %q", attr)
+			}
+			if err := p.expect('='); err != nil {
+				return nil, err
+			}
+			typ, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			attrs[attr] = typ
+			// attributes may be separated by commas or by newlines alone
+			p.accept(',')
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return tftypes.Object{AttributeTypes: attrs}, nil
+	case "":
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("expected a type, found the end of the expression")
+		}
+		return nil, p.errorf("expected a type, found %q", p.src[p.pos])
+	default:
+		return nil, p.errorf("unknown type %q", name)
+	}
+}
+
+// convertValue converts v to typ the way Terraform converts values for type constraints:
+// tuples become lists or sets, objects become maps, and primitives convert to and from strings.
+// Errors are tftypes.AttributePathErrors rooted at path.
+// Where typ contains "any", the result has the concrete type of the value instead.
+func convertValue(path *tftypes.AttributePath, v tftypes.Value, typ tftypes.Type) (tftypes.Value, error) {
+	if typ.Is(tftypes.DynamicPseudoType) {
+		return v, nil
+	}
+	if !v.IsKnown() {
+		return tftypes.NewValue(typ, tftypes.UnknownValue), nil
+	}
+	if v.IsNull() {
+		return tftypes.NewValue(typ, nil), nil
+	}
+
+	from := v.Type()
+	switch {
+	case typ.Is(tftypes.String):
+		switch {
+		case from.Is(tftypes.String):
+			return v, nil
+		case from.Is(tftypes.Number):
+			n := new(big.Float)
+			_ = v.As(&n)
+			return tftypes.NewValue(tftypes.String, n.Text('f', -1)), nil
+		case from.Is(tftypes.Bool):
+			var b bool
+			_ = v.As(&b)
+			return tftypes.NewValue(tftypes.String, fmt.Sprint(b)), nil
+		}
+	case typ.Is(tftypes.Number):
+		switch {
+		case from.Is(tftypes.Number):
+			return v, nil
+		case from.Is(tftypes.String):
+			var s string
+			_ = v.As(&s)
+			n, _, err := big.ParseFloat(strings.TrimSpace(s), 10, 512, big.ToNearestEven)
+			if err != nil {
+				return tftypes.Value{}, path.NewErrorf("a number is required, got %q", s)
+			}
+			return tftypes.NewValue(tftypes.Number, n), nil
+		}
+	case typ.Is(tftypes.Bool):
+		switch {
+		case from.Is(tftypes.Bool):
+			return v, nil
+		case from.Is(tftypes.String):
+			var s string
+			_ = v.As(&s)
+			switch s {
+			case "true":
+				return tftypes.NewValue(tftypes.Bool, true), nil
+			case "false":
+				return tftypes.NewValue(tftypes.Bool, false), nil
+			}
+			return tftypes.Value{}, path.NewErrorf("a bool is required, got %q", s)
+		}
+	case typ.Is(tftypes.List{}), typ.Is(tftypes.Set{}):
+		if !from.Is(tftypes.List{}) && !from.Is(tftypes.Set{}) && !from.Is(tftypes.Tuple{}) {
+			break
+		}
+		var elems []tftypes.Value
+		_ = v.As(&elems)
+		var elemType tftypes.Type
+		if l, ok := typ.(tftypes.List); ok {
+			elemType = l.ElementType
+		} else {
+			elemType = typ.(tftypes.Set).ElementType
+		}
+		converted, elemType, err := convertElements(path, elems, elemType, func(p *tftypes.AttributePath, i int) *tftypes.AttributePath {
+			return p.WithElementKeyInt(i)
+		})
+		if err != nil {
+			return tftypes.Value{}, err
+		}
+		if typ.Is(tftypes.List{}) {
+			return tftypes.NewValue(tftypes.List{ElementType: elemType}, converted), nil
+		}
+		return tftypes.NewValue(tftypes.Set{ElementType: elemType}, uniqueElements(converted)), nil
+	case typ.Is(tftypes.Map{}):
+		if !from.Is(tftypes.Map{}) && !from.Is(tftypes.Object{}) {
+			break
+		}
+		elems := map[string]tftypes.Value{}
+		_ = v.As(&elems)
+		keys := make([]string, 0, len(elems))
+		for k := range elems {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		list := make([]tftypes.Value, len(keys))
+		for i, k := range keys {
+			list[i] = elems[k]
+		}
+		converted, elemType, err := convertElements(path, list, typ.(tftypes.Map).ElementType, func(p *tftypes.AttributePath, i int) *tftypes.AttributePath {
+			return p.WithElementKeyString(keys[i])
+		})
+		if err != nil {
+			return tftypes.Value{}, err
+		}
+		out := make(map[string]tftypes.Value, len(keys))
+		for i, k := range keys {
+			out[k] = converted[i]
+		}
+		return tftypes.NewValue(tftypes.Map{ElementType: elemType}, out), nil
+	case typ.Is(tftypes.Tuple{}):
+		if !from.Is(tftypes.List{}) && !from.Is(tftypes.Tuple{}) {
+			break
+		}
+		var elems []tftypes.Value
+		_ = v.As(&elems)
+		want := typ.(tftypes.Tuple).ElementTypes
+		if len(elems) != len(want) {
+			return tftypes.Value{}, path.NewErrorf("a tuple of %d elements is required, got %d", len(want), len(elems))
+		}
+		out := make([]tftypes.Value, len(elems))
+		types := make([]tftypes.Type, len(elems))
+		for i, e := range elems {
+			c, err := convertValue(path.WithElementKeyInt(i), e, want[i])
+			if err != nil {
+				return tftypes.Value{}, err
+			}
+			out[i], types[i] = c, c.Type()
+		}
+		return tftypes.NewValue(tftypes.Tuple{ElementTypes: types}, out), nil
+	case typ.Is(tftypes.Object{}):
+		if !from.Is(tftypes.Object{}) && !from.Is(tftypes.Map{}) {
+			break
+		}
+		attrs := map[string]tftypes.Value{}
+		_ = v.As(&attrs)
+		want := typ.(tftypes.Object).AttributeTypes
+		out := make(map[string]tftypes.Value, len(want))
+		types := make(map[string]tftypes.Type, len(want))
+		names := make([]string, 0, len(want))
+		for name := range want {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			attr, ok := attrs[name]
+			if !ok {
+				return tftypes.Value{}, path.NewErrorf("attribute %q is required", name)
+			}
+			step := path.WithAttributeName(name)
+			if from.Is(tftypes.Map{}) {
+				step = path.WithElementKeyString(name)
+			}
+			c, err := convertValue(step, attr, want[name])
+			if err != nil {
+				return tftypes.Value{}, err
+			}
+			out[name], types[name] = c, c.Type()
+		}
+		return tftypes.NewValue(tftypes.Object{AttributeTypes: types}, out), nil
+	}
+	return tftypes.Value{}, path.NewErrorf("%s is required, got %s", typeName(typ), typeName(from))
+}
+
+// convertElements converts the elements of a collection to elemType. When elemType is "any",
+// all elements must convert to the same type, which becomes the element type of the collection.
+func convertElements(path *tftypes.AttributePath, elems []tftypes.Value, elemType tftypes.Type, step func(*tftypes.AttributePath, int) *tftypes.AttributePath) ([]tftypes.Value, tftypes.Type, error) {
+	out := make([]tftypes.Value, len(elems))
+	for i, e := range elems {
+		c, err := convertValue(step(path, i), e, elemType)
+		if err != nil {
+			return nil, nil, err
+		}
+		out[i] = c
+	}
+	if !elemType.Is(tftypes.DynamicPseudoType) || len(out) == 0 {
+		return out, elemType, nil
+	}
+
+	// Unknown elements of unknown type take whatever type the others unify to, and differing primitives unify to string.
+	var concrete tftypes.Type
+	for i, c := range out {
+		t := c.Type()
+		switch {
+		case t.Is(tftypes.DynamicPseudoType):
+		case concrete == nil, concrete.Equal(t):
+			concrete = t
+		case isPrimitiveType(concrete) && isPrimitiveType(t):
+			concrete = tftypes.String
+		default:
+			return nil, nil, step(path, i).NewErrorf("all elements must have the same type, got %s and %s", typeName(concrete), typeName(t))
+		}
+	}
+	if concrete == nil {
+		return out, elemType, nil
+	}
+	for i, c := range out {
+		converted, err := convertValue(step(path, i), c, concrete)
+		if err != nil {
+			return nil, nil, err
+		}
+		out[i] = converted
+	}
+	return out, concrete, nil
+}
+
+func isPrimitiveType(t tftypes.Type) bool {
+	return t.Is(tftypes.String) || t.Is(tftypes.Number) || t.Is(tftypes.Bool)
+}
+
+// uniqueElements drops the known elements of a set that equal an earlier one, as Terraform does when converting to a set.
+// Unknown elements are kept, since they may or may not turn out to be duplicates.
+func uniqueElements(elems []tftypes.Value) []tftypes.Value {
+	out := make([]tftypes.Value, 0, len(elems))
+	for _, e := range elems {
+		duplicate := false
+		if e.IsFullyKnown() {
+			for _, kept := range out {
+				if kept.Equal(e) {
+					duplicate = true
+					break
+				}
+			}
+		}
+		if !duplicate {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// typeName describes a type the way it is written in a type constraint.
+func typeName(t tftypes.Type) string {
+	switch {
+	case t.Is(tftypes.String):
+		return "string"
+	case t.Is(tftypes.Number):
+		return "number"
+	case t.Is(tftypes.Bool):
+		return "bool"
+	case t.Is(tftypes.DynamicPseudoType):
+		return "any"
+	case t.Is(tftypes.List{}):
+		return "list(" + typeName(t.(tftypes.List).ElementType) + ")"
+	case t.Is(tftypes.Set{}):
+		return "set(" + typeName(t.(tftypes.Set).ElementType) + ")"
+	case t.Is(tftypes.Map{}):
+		return "map(" + typeName(t.(tftypes.Map).ElementType) + ")"
+	case t.Is(tftypes.Tuple{}):
+		return "tuple"
+	case t.Is(tftypes.Object{}):
+		return "object"
+	}
+	return t.String()
+}
+
+// conversionErrorPath splits an error returned by convertValue into the path it refers to and its message.
+func conversionErrorPath(err error) (*tftypes.AttributePath, string) {
+	var pathErr tftypes.AttributePathError
+	if errors.As(err, &pathErr) {
+		if inner := errors.Unwrap(pathErr); inner != nil {
+			return pathErr.Path, inner.Error()
+		}
+		return pathErr.Path, err.Error()
+	}
+	return nil, err.Error()
+}
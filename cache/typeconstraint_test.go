@@ -0,0 +1,191 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestParseTypeConstraint(t *testing.T) {
+	cases := map[string]tftypes.Type{
+		"string":           tftypes.String,
+		" list( number ) ": tftypes.List{ElementType: tftypes.Number},
+		"map(any)":         tftypes.Map{ElementType: tftypes.DynamicPseudoType},
+		"tuple([string, bool])": tftypes.Tuple{ElementTypes: []tftypes.Type{
+			tftypes.String, tftypes.Bool,
+		}},
+		"object({ id = string, ports = set(number) })": tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+			"id":    tftypes.String,
+			"ports": tftypes.Set{ElementType: tftypes.Number},
+		}},
+		"object({\n  id = string\n  tags = map(string)\n})": tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+			"id":   tftypes.String,
+			"tags": tftypes.Map{ElementType: tftypes.String},
+		}},
+	}
+	for expr, expected := range cases {
+		typ, err := parseTypeConstraint(expr)
+		if err != nil {
+			t.Errorf("%q: %s", expr, err)
+		} else if !typ.Equal(expected) {
+			t.Errorf("%q parsed as %s, expected %s", expr, typ, expected)
+		}
+	}
+
+	for _, expr := range []string{"", "strin", "list(string", "map()", "object({ id = string, id = number })", "string string"} {
+		if _, err := parseTypeConstraint(expr); err == nil {
+			t.Errorf("%q should not parse", expr)
+		}
+	}
+}
+
+func TestConvertValue(t *testing.T) {
+	tuple := tftypes.NewValue(tftypes.Tuple{ElementTypes: []tftypes.Type{tftypes.String, tftypes.Number}}, []tftypes.Value{
+		tftypes.NewValue(tftypes.String, "1"),
+		tftypes.NewValue(tftypes.Number, 2),
+	})
+	converted, err := convertValue(tftypes.NewAttributePath(), tuple, tftypes.List{ElementType: tftypes.Number})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := tftypes.NewValue(tftypes.List{ElementType: tftypes.Number}, []tftypes.Value{
+		tftypes.NewValue(tftypes.Number, 1),
+		tftypes.NewValue(tftypes.Number, 2),
+	})
+	if !converted.Equal(expected) {
+		t.Errorf("converted to %s, expected %s", converted, expected)
+	}
+
+	object := tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"ports": tftypes.Tuple{ElementTypes: []tftypes.Type{tftypes.String}},
+	}}, map[string]tftypes.Value{
+		"ports": tftypes.NewValue(tftypes.Tuple{ElementTypes: []tftypes.Type{tftypes.String}}, []tftypes.Value{
+			tftypes.NewValue(tftypes.String, "http"),
+		}),
+	})
+	_, err = convertValue(tftypes.NewAttributePath().WithAttributeName("value"), object, tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"ports": tftypes.List{ElementType: tftypes.Number},
+	}})
+	path, msg := conversionErrorPath(err)
+	if !path.Equal(tftypes.NewAttributePath().WithAttributeName("value").WithAttributeName("ports").WithElementKeyInt(0)) {
+		t.Errorf("error at %s, expected value.ports[0]", path)
+	}
+	if msg != `a number is required, got "http"` {
+		t.Errorf("unexpected error %q", msg)
+	}
+}
+
+func TestConvertValueUnifiesAny(t *testing.T) {
+	str := func(s string) tftypes.Value { return tftypes.NewValue(tftypes.String, s) }
+	unknown := tftypes.NewValue(tftypes.DynamicPseudoType, tftypes.UnknownValue)
+	mixed := tftypes.Tuple{ElementTypes: []tftypes.Type{tftypes.String, tftypes.Number, tftypes.Bool}}
+	partial := tftypes.Tuple{ElementTypes: []tftypes.Type{tftypes.String, tftypes.DynamicPseudoType}}
+	strList := tftypes.List{ElementType: tftypes.String}
+
+	cases := map[string]struct {
+		v        tftypes.Value
+		typ      tftypes.Type
+		expected tftypes.Value
+	}{
+		"mixed primitives": {
+			tftypes.NewValue(mixed, []tftypes.Value{str("a"), tftypes.NewValue(tftypes.Number, 1), tftypes.NewValue(tftypes.Bool, true)}),
+			tftypes.List{ElementType: tftypes.DynamicPseudoType},
+			tftypes.NewValue(strList, []tftypes.Value{str("a"), str("1"), str("true")}),
+		},
+		"unknown list element": {
+			tftypes.NewValue(partial, []tftypes.Value{str("a"), unknown}),
+			tftypes.List{ElementType: tftypes.DynamicPseudoType},
+			tftypes.NewValue(strList, []tftypes.Value{str("a"), tftypes.NewValue(tftypes.String, tftypes.UnknownValue)}),
+		},
+		"unknown set element": {
+			tftypes.NewValue(partial, []tftypes.Value{str("a"), unknown}),
+			tftypes.Set{ElementType: tftypes.DynamicPseudoType},
+			tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, []tftypes.Value{str("a"), tftypes.NewValue(tftypes.String, tftypes.UnknownValue)}),
+		},
+		"unknown map element": {
+			tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{"a": tftypes.String, "b": tftypes.DynamicPseudoType}}, map[string]tftypes.Value{"a": str("x"), "b": unknown}),
+			tftypes.Map{ElementType: tftypes.DynamicPseudoType},
+			tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, map[string]tftypes.Value{"a": str("x"), "b": tftypes.NewValue(tftypes.String, tftypes.UnknownValue)}),
+		},
+		"duplicate set elements": {
+			tftypes.NewValue(tftypes.Tuple{ElementTypes: []tftypes.Type{tftypes.String, tftypes.String, tftypes.Number}}, []tftypes.Value{str("a"), str("a"), tftypes.NewValue(tftypes.Number, 1)}),
+			tftypes.Set{ElementType: tftypes.String},
+			tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, []tftypes.Value{str("a"), str("1")}),
+		},
+		"duplicates after conversion": {
+			tftypes.NewValue(tftypes.Tuple{ElementTypes: []tftypes.Type{tftypes.String, tftypes.Number}}, []tftypes.Value{str("1"), tftypes.NewValue(tftypes.Number, 1)}),
+			tftypes.Set{ElementType: tftypes.DynamicPseudoType},
+			tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, []tftypes.Value{str("1")}),
+		},
+	}
+	for name, c := range cases {
+		converted, err := convertValue(tftypes.NewAttributePath(), c.v, c.typ)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+		} else if !converted.Equal(c.expected) {
+			t.Errorf("%s: converted to %s, expected %s", name, converted, c.expected)
+		}
+	}
+
+	objects := tftypes.Tuple{ElementTypes: []tftypes.Type{tftypes.String, tftypes.List{ElementType: tftypes.String}}}
+	v := tftypes.NewValue(objects, []tftypes.Value{str("a"), tftypes.NewValue(strList, []tftypes.Value{})})
+	if _, err := convertValue(tftypes.NewAttributePath(), v, tftypes.List{ElementType: tftypes.DynamicPseudoType}); err == nil {
+		t.Error("a string and a list should not unify")
+	}
+}
+
+func TestHarnessStoreUnknownElementWithAnyConstraint(t *testing.T) {
+	h := newTestHarness(t, "cache_store")
+	partial := tftypes.Tuple{ElementTypes: []tftypes.Type{tftypes.String, tftypes.DynamicPseudoType}}
+	config := map[string]tftypes.Value{
+		"value": tftypes.NewValue(partial, []tftypes.Value{
+			tftypes.NewValue(tftypes.String, "a"),
+			tftypes.NewValue(tftypes.DynamicPseudoType, tftypes.UnknownValue),
+		}),
+		"type_constraint": tftypes.NewValue(tftypes.String, "list(any)"),
+	}
+	assertNoDiagnostics(t, h.validate(config))
+	_, diags := h.plan(config)
+	assertNoDiagnostics(t, diags)
+}
+
+func TestHarnessStoreTypeConstraintRemoved(t *testing.T) {
+	tuple := tftypes.Tuple{ElementTypes: []tftypes.Type{tftypes.String, tftypes.String}}
+	value := func(a, b string) tftypes.Value {
+		return tftypes.NewValue(tuple, []tftypes.Value{tftypes.NewValue(tftypes.String, a), tftypes.NewValue(tftypes.String, b)})
+	}
+	constraint := tftypes.NewValue(tftypes.String, "list(string)")
+
+	t.Run("captured", func(t *testing.T) {
+		h := newTestHarness(t, "cache_store")
+		assertNoDiagnostics(t, h.step(map[string]tftypes.Value{"value": value("a", "b"), "type_constraint": constraint}))
+
+		config := map[string]tftypes.Value{"value": value("a", "b")}
+		assertNoDiagnostics(t, h.step(config))
+		if !h.state["value"].Equal(value("a", "b")) || !h.state["result"].IsNull() {
+			t.Errorf("expected the unconverted value without result, got value %s and result %s", h.state["value"], h.state["result"])
+		}
+		h.assertNoChanges(config)
+
+		// A value that actually differs is still reported.
+		diags := h.step(map[string]tftypes.Value{"value": value("a", "c")})
+		assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityWarning, "differs from cached value")
+	})
+
+	t.Run("held after null", func(t *testing.T) {
+		h := newTestHarness(t, "cache_store")
+		assertNoDiagnostics(t, h.step(map[string]tftypes.Value{"value": value("a", "b"), "type_constraint": constraint}))
+		assertDiagnostic(t, h.step(map[string]tftypes.Value{
+			"value":           tftypes.NewValue(tftypes.DynamicPseudoType, nil),
+			"type_constraint": constraint,
+		}), tfprotov5.DiagnosticSeverityWarning, "Configured value is null")
+
+		config := map[string]tftypes.Value{"value": value("a", "b")}
+		assertNoDiagnostics(t, h.step(config))
+		if !h.state["value"].Equal(value("a", "b")) || !h.state["result"].IsNull() {
+			t.Errorf("expected the unconverted value without result, got value %s and result %s", h.state["value"], h.state["result"])
+		}
+		h.assertNoChanges(config)
+	})
+}
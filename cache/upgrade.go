@@ -0,0 +1,195 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// stateUpgrader upgrades the decoded JSON state of a resource by one schema version.
+type stateUpgrader func(state map[string]interface{}) error
+
+// stateUpgraders holds the upgrade steps of each resource type. The step at index i upgrades a state from version i to i+1,
+// so a resource with schema version n has n steps. Add a step here whenever the schema version of a resource is bumped.
+var stateUpgraders = map[string][]stateUpgrader{
+	"cache_store": {
+		upgradeStoreV0,
+		upgradeStoreV1,
+	},
+}
+
+// UpgradeResourceState runs the upgrade steps from the version the state was written with to the current schema version,
+// then decodes the state against the current schema.
+func (s *RawProviderServer) UpgradeResourceState(ctx context.Context, req *tfprotov5.UpgradeResourceStateRequest) (*tfprotov5.UpgradeResourceStateResponse, error) {
+	resp := &tfprotov5.UpgradeResourceStateResponse{}
+	resp.Diagnostics = []*tfprotov5.Diagnostic{}
+
+	sch, ok := GetProviderResourceSchema()[req.TypeName]
+	if !ok {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  "Failed to determine resource type",
+			Detail:   fmt.Sprintf("unknown resource %s - cannot find schema", req.TypeName),
+		})
+		return resp, nil
+	}
+	rt := GetObjectTypeFromSchema(sch).(tftypes.Object)
+
+	state, err := decodeRawState(req.RawState)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  "Failed to decode old state during upgrade",
+			Detail:   err.Error(),
+		})
+		return resp, nil
+	}
+	if err := upgradeState(req.TypeName, req.Version, sch.Version, state); err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  "Failed to upgrade state",
+			Detail:   err.Error(),
+		})
+		return resp, nil
+	}
+	s.logger.Trace("[UpgradeResourceState]", "[UpgradedState]", dump(state))
+
+	vals, err := stateValues(state, rt)
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  "Failed to decode upgraded state",
+			Detail:   err.Error(),
+		})
+		return resp, nil
+	}
+	us, err := tfprotov5.NewDynamicValue(rt, tftypes.NewValue(rt, vals))
+	if err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity: tfprotov5.DiagnosticSeverityError,
+			Summary:  "Failed to encode new state during upgrade",
+			Detail:   err.Error(),
+		})
+	}
+	resp.UpgradedState = &us
+
+	return resp, nil
+}
+
+// upgradeState applies the upgrade steps of a resource type from one schema version to another.
+func upgradeState(typeName string, from, to int64, state map[string]interface{}) error {
+	if from > to {
+		return fmt.Errorf("the state of this %s was written with schema version %d by a newer version of the provider, which cannot be downgraded to version %d", typeName, from, to)
+	}
+	steps := stateUpgraders[typeName]
+	for v := from; v < to; v++ {
+		if v >= int64(len(steps)) {
+			return fmt.Errorf("no upgrade from schema version %d of %s", v, typeName)
+		}
+		if err := steps[v](state); err != nil {
+			return fmt.Errorf("upgrading %s from schema version %d: %w", typeName, v, err)
+		}
+	}
+	return nil
+}
+
+// decodeRawState decodes the JSON state of a resource, or the flatmap state of the legacy SDK.
+// Numbers are kept as json.Number so that they are not rounded.
+func decodeRawState(raw *tfprotov5.RawState) (map[string]interface{}, error) {
+	if raw == nil {
+		return nil, fmt.Errorf("no state to upgrade")
+	}
+	if raw.JSON != nil {
+		state := map[string]interface{}{}
+		dec := json.NewDecoder(bytes.NewReader(raw.JSON))
+		dec.UseNumber()
+		if err := dec.Decode(&state); err != nil {
+			return nil, err
+		}
+		return state, nil
+	}
+	return flatmapState(raw.Flatmap)
+}
+
+// flatmapState converts a flatmap state into its JSON form. Only top-level primitive attributes can be converted,
+// since the flatmap does not record their types; they are decoded as strings by the schema.
+func flatmapState(flatmap map[string]string) (map[string]interface{}, error) {
+	state := map[string]interface{}{}
+	for k, v := range flatmap {
+		if k == "id" {
+			continue
+		}
+		if strings.ContainsAny(k, ".%#") {
+			return nil, fmt.Errorf("cannot upgrade nested attribute %q from flatmap state", k)
+		}
+		state[k] = v
+	}
+	return state, nil
+}
+
+// stateValues decodes an upgraded state into the attributes of rt.
+// Attributes added since the state was written are null, and attributes that were removed are dropped.
+func stateValues(state map[string]interface{}, rt tftypes.Object) (map[string]tftypes.Value, error) {
+	present := map[string]tftypes.Type{}
+	known := map[string]interface{}{}
+	for name, typ := range rt.AttributeTypes {
+		if a, ok := state[name]; ok {
+			present[name] = typ
+			known[name] = a
+		}
+	}
+	data, err := json.Marshal(known)
+	if err != nil {
+		return nil, err
+	}
+	v, err := tftypes.ValueFromJSON(data, tftypes.Object{AttributeTypes: present})
+	if err != nil {
+		return nil, err
+	}
+	vals := map[string]tftypes.Value{}
+	if err := v.As(&vals); err != nil {
+		return nil, err
+	}
+	for name, typ := range rt.AttributeTypes {
+		if _, ok := vals[name]; !ok {
+			vals[name] = tftypes.NewValue(typ, nil)
+		}
+	}
+	return vals, nil
+}
+
+// upgradeStoreV0 upgrades a cache_store from version 0. The schema was released as version 1 without changes.
+func upgradeStoreV0(state map[string]interface{}) error {
+	return nil
+}
+
+// upgradeStoreV1 records the capture metadata of version 2, deriving 'created_at' from the original Unix 'timestamp',
+// which is kept as is.
+func upgradeStoreV1(state map[string]interface{}) error {
+	if created, ok := state["created_at"]; ok && created != nil {
+		return nil
+	}
+	ts, _ := state["timestamp"].(string)
+	if ts == "" {
+		return nil
+	}
+	secs, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("timestamp %q is not in Unix seconds: %w", ts, err)
+	}
+	state["created_at"] = time.Unix(secs, 0).UTC().Format(time.RFC3339Nano)
+
+	count := 1
+	if captured, ok := state["captured"].(bool); ok && !captured {
+		count = 0
+	}
+	state["capture_count"] = count
+	return nil
+}
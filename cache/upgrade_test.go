@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func upgradeStore(t *testing.T, version int64, raw *tfprotov5.RawState) *tfprotov5.UpgradeResourceStateResponse {
+	t.Helper()
+	s := &RawProviderServer{logger: hclog.NewNullLogger()}
+	resp, err := s.UpgradeResourceState(context.Background(), &tfprotov5.UpgradeResourceStateRequest{
+		TypeName: "cache_store",
+		Version:  version,
+		RawState: raw,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestStateUpgradersCoverSchemaVersions(t *testing.T) {
+	for name, sch := range GetProviderResourceSchema() {
+		if steps := int64(len(stateUpgraders[name])); steps != sch.Version {
+			t.Errorf("%s is at schema version %d but has %d upgrade steps", name, sch.Version, steps)
+		}
+	}
+}
+
+func TestUpgradeStoreV0(t *testing.T) {
+	state := map[string]interface{}{"value": map[string]interface{}{"value": "ami-123", "type": "string"}, "timestamp": "1634000000"}
+	if err := upgradeStoreV0(state); err != nil {
+		t.Fatal(err)
+	}
+	if len(state) != 2 {
+		t.Errorf("version 0 states should not change, got %v", state)
+	}
+}
+
+func TestUpgradeStoreV1(t *testing.T) {
+	cases := map[string]struct {
+		state     map[string]interface{}
+		createdAt interface{}
+		count     interface{}
+	}{
+		"captured": {
+			state:     map[string]interface{}{"timestamp": "1634000000", "captured": true},
+			createdAt: "2021-10-12T00:53:20Z",
+			count:     1,
+		},
+		"before capture_when": {
+			state:     map[string]interface{}{"timestamp": "1634000000"},
+			createdAt: "2021-10-12T00:53:20Z",
+			count:     1,
+		},
+		"pending": {
+			state:     map[string]interface{}{"timestamp": "1634000000", "captured": false},
+			createdAt: "2021-10-12T00:53:20Z",
+			count:     0,
+		},
+		"no timestamp": {
+			state: map[string]interface{}{},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if err := upgradeStoreV1(c.state); err != nil {
+				t.Fatal(err)
+			}
+			if c.state["created_at"] != c.createdAt {
+				t.Errorf("created_at upgraded to %v, expected %v", c.state["created_at"], c.createdAt)
+			}
+			if c.state["capture_count"] != c.count {
+				t.Errorf("capture_count upgraded to %v, expected %v", c.state["capture_count"], c.count)
+			}
+		})
+	}
+
+	if err := upgradeStoreV1(map[string]interface{}{"timestamp": "yesterday"}); err == nil {
+		t.Error("a timestamp that is not in Unix seconds should fail the upgrade")
+	}
+}
+
+func TestUpgradeStoreKeepsTimestamp(t *testing.T) {
+	resp := upgradeStore(t, 1, &tfprotov5.RawState{
+		JSON: []byte(`{"value":{"value":"ami-123","type":"string"},"timestamp":"1634000000","removed_attribute":true}`),
+	})
+	if len(resp.Diagnostics) > 0 {
+		t.Fatalf("unexpected diagnostic: %s: %s", resp.Diagnostics[0].Summary, resp.Diagnostics[0].Detail)
+	}
+	state := decodeState(t, "cache_store", resp.UpgradedState)
+
+	expected := map[string]tftypes.Value{
+		"value":         tftypes.NewValue(tftypes.String, "ami-123"),
+		"timestamp":     tftypes.NewValue(tftypes.String, "1634000000"),
+		"created_at":    tftypes.NewValue(tftypes.String, "2021-10-12T00:53:20Z"),
+		"capture_count": tftypes.NewValue(tftypes.Number, 1),
+	}
+	for name, v := range expected {
+		if !state[name].Equal(v) {
+			t.Errorf("%s upgraded to %s, expected %s", name, state[name], v)
+		}
+	}
+}
+
+func TestUpgradeStoreFlatmap(t *testing.T) {
+	resp := upgradeStore(t, 0, &tfprotov5.RawState{
+		Flatmap: map[string]string{"id": "x", "timestamp": "1634000000", "strict": "true"},
+	})
+	if len(resp.Diagnostics) > 0 {
+		t.Fatalf("unexpected diagnostic: %s: %s", resp.Diagnostics[0].Summary, resp.Diagnostics[0].Detail)
+	}
+	state := decodeState(t, "cache_store", resp.UpgradedState)
+	if !state["created_at"].Equal(tftypes.NewValue(tftypes.String, "2021-10-12T00:53:20Z")) {
+		t.Errorf("created_at upgraded to %s", state["created_at"])
+	}
+
+	resp = upgradeStore(t, 0, &tfprotov5.RawState{
+		Flatmap: map[string]string{"tags.%": "1", "tags.owner": "me"},
+	})
+	if len(resp.Diagnostics) == 0 {
+		t.Error("nested flatmap attributes should not upgrade")
+	}
+}
+
+func TestUpgradeStoreFromNewerVersion(t *testing.T) {
+	resp := upgradeStore(t, 99, &tfprotov5.RawState{JSON: []byte(`{}`)})
+	if len(resp.Diagnostics) == 0 {
+		t.Error("a state from a newer schema version should not upgrade")
+	}
+}
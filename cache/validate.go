@@ -2,7 +2,6 @@ package cache
 
 import (
 	"context"
-	"log"
 
 	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
 	"github.com/hashicorp/terraform-plugin-go/tftypes"
@@ -11,8 +10,6 @@ import (
 // ValidateResourceTypeConfig function
 func (s *RawProviderServer) ValidateResourceTypeConfig(ctx context.Context, req *tfprotov5.ValidateResourceTypeConfigRequest) (*tfprotov5.ValidateResourceTypeConfigResponse, error) {
 	resp := &tfprotov5.ValidateResourceTypeConfigResponse{}
-	// requiredKeys := []string{"apiVersion", "kind", "metadata"}
-	// forbiddenKeys := []string{"status"}
 
 	rt, err := GetResourceType(req.TypeName)
 	if err != nil {
@@ -23,8 +20,7 @@ func (s *RawProviderServer) ValidateResourceTypeConfig(ctx context.Context, req
 		})
 		return resp, nil
 	}
-	log.Println("--------------------TEST----------------------")
-	log.Printf("ResourceType: %v\n", rt)
+	s.logger.Trace("[ValidateResourceTypeConfig]", "[ResourceType]", dump(rt))
 
 	// Decode proposed resource state
 	config, err := req.Config.Unmarshal(rt)
@@ -38,7 +34,7 @@ func (s *RawProviderServer) ValidateResourceTypeConfig(ctx context.Context, req
 	}
 
 	att := tftypes.NewAttributePath()
-	att = att.WithAttributeName("manifest")
+	att = att.WithAttributeName("value")
 
 	configVal := make(map[string]tftypes.Value)
 	err = config.As(&configVal)
@@ -51,6 +47,21 @@ func (s *RawProviderServer) ValidateResourceTypeConfig(ctx context.Context, req
 		return resp, nil
 	}
 
+	switch req.TypeName {
+	case "cache_version_pin":
+		resp.Diagnostics = append(resp.Diagnostics, validateVersionPin(configVal)...)
+		return resp, nil
+	case "cache_file":
+		resp.Diagnostics = append(resp.Diagnostics, validateFile(configVal)...)
+		return resp, nil
+	case "cache_command":
+		resp.Diagnostics = append(resp.Diagnostics, validateCommand(configVal)...)
+		return resp, nil
+	case "cache_env":
+		resp.Diagnostics = append(resp.Diagnostics, validateEnv(configVal)...)
+		return resp, nil
+	}
+
 	_, ok := configVal["value"]
 	if !ok {
 		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
@@ -62,46 +73,9 @@ func (s *RawProviderServer) ValidateResourceTypeConfig(ctx context.Context, req
 		return resp, nil
 	}
 
-	// rawManifest := make(map[string]tftypes.Value)
-	// err = manifest.As(&rawManifest)
-	// if err != nil {
-	// 	if err.Error() == "unmarshaling unknown values is not supported" {
-	// 		// Likely this validation call came too early and the manifest still contains unknown values.
-	// 		// Bailing out without error to allow the resource to be completed at a later stage.
-	// 		return resp, nil
-	// 	}
-	// 	resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
-	// 		Severity:  tfprotov5.DiagnosticSeverityError,
-	// 		Summary:   `Failed to extract "manifest" attribute value from resource configuration`,
-	// 		Detail:    err.Error(),
-	// 		Attribute: att,
-	// 	})
-	// 	return resp, nil
-	// }
-
-	// for _, key := range requiredKeys {
-	// 	if _, present := rawManifest[key]; !present {
-	// 		kp := att.WithAttributeName(key)
-	// 		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
-	// 			Severity:  tfprotov5.DiagnosticSeverityError,
-	// 			Summary:   `Attribute key missing from "manifest" value`,
-	// 			Detail:    fmt.Sprintf("'%s' attribute key is missing from manifest configuration", key),
-	// 			Attribute: kp,
-	// 		})
-	// 	}
-	// }
-
-	// for _, key := range forbiddenKeys {
-	// 	if _, present := rawManifest[key]; present {
-	// 		kp := att.WithAttributeName(key)
-	// 		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
-	// 			Severity:  tfprotov5.DiagnosticSeverityError,
-	// 			Summary:   `Forbidden attribute key in "manifest" value`,
-	// 			Detail:    fmt.Sprintf("'%s' attribute key is not allowed in manifest configuration", key),
-	// 			Attribute: kp,
-	// 		})
-	// 	}
-	// }
+	// Validation runs before the configuration is fully known, so values are only checked once they are known,
+	// and an unknown 'value' (or any unknown element of it) is not an error.
+	resp.Diagnostics = append(resp.Diagnostics, validateStore(configVal)...)
 
 	return resp, nil
 }
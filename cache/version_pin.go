@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	pinPolicyNone  = "none"
+	pinPolicyPatch = "patch"
+	pinPolicyMinor = "minor"
+)
+
+// canonicalVersion adds the "v" prefix the semver package expects, so versions can be configured either way.
+func canonicalVersion(v string) string {
+	if strings.HasPrefix(v, "v") {
+		return v
+	}
+	return "v" + v
+}
+
+// sameVersion reports whether two versions are equal, whether or not they are written with the "v" prefix.
+func sameVersion(a, b string) bool {
+	if a == b {
+		return true
+	}
+	// semver considers all invalid versions equal
+	ca, cb := canonicalVersion(a), canonicalVersion(b)
+	return semver.IsValid(ca) && semver.IsValid(cb) && semver.Compare(ca, cb) == 0
+}
+
+// pinUpgradeAllowed reports whether policy permits moving a pin from the pinned version to the incoming one.
+// Downgrades are never allowed.
+func pinUpgradeAllowed(policy, pinned, incoming string) bool {
+	from, to := canonicalVersion(pinned), canonicalVersion(incoming)
+	if !semver.IsValid(from) || !semver.IsValid(to) || semver.Compare(to, from) <= 0 {
+		return false
+	}
+	switch policy {
+	case pinPolicyPatch:
+		return semver.MajorMinor(from) == semver.MajorMinor(to)
+	case pinPolicyMinor:
+		return semver.Major(from) == semver.Major(to)
+	default:
+		return false
+	}
+}
+
+func validateVersionPin(configVal map[string]tftypes.Value) (diags []*tfprotov5.Diagnostic) {
+	if v := configVal["version"]; v.IsKnown() && !v.IsNull() {
+		var version string
+		if err := v.As(&version); err == nil && !semver.IsValid(canonicalVersion(version)) {
+			diags = append(diags, &tfprotov5.Diagnostic{
+				Severity:  tfprotov5.DiagnosticSeverityError,
+				Summary:   "Invalid version",
+				Detail:    fmt.Sprintf("%q is not a valid semantic version.", version),
+				Attribute: tftypes.NewAttributePath().WithAttributeName("version"),
+			})
+		}
+	}
+	if p := configVal["policy"]; p.IsKnown() && !p.IsNull() {
+		var policy string
+		if err := p.As(&policy); err == nil {
+			switch policy {
+			case pinPolicyNone, pinPolicyPatch, pinPolicyMinor:
+			default:
+				diags = append(diags, &tfprotov5.Diagnostic{
+					Severity:  tfprotov5.DiagnosticSeverityError,
+					Summary:   "Invalid pin policy",
+					Detail:    fmt.Sprintf("Policy must be one of %q, %q or %q, got %q.", pinPolicyPatch, pinPolicyMinor, pinPolicyNone, policy),
+					Attribute: tftypes.NewAttributePath().WithAttributeName("policy"),
+				})
+			}
+		}
+	}
+	return
+}
+
+// planVersionPin only moves the pin when the incoming version is an upgrade permitted by the policy.
+// Prohibited version changes are recorded in 'version' but leave 'pinned_version' alone.
+func (s *RawProviderServer) planVersionPin(priorState tftypes.Value, priorVal, proposedVal map[string]tftypes.Value, resp *tfprotov5.PlanResourceChangeResponse) {
+	version := proposedVal["version"]
+	switch {
+	case priorState.IsNull():
+		proposedVal["pinned_version"] = version
+		proposedVal["timestamp"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+	case !version.IsKnown() || !proposedVal["policy"].IsKnown():
+		// the decision has to wait for apply
+		proposedVal["pinned_version"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+		proposedVal["timestamp"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+	default:
+		var incoming, pinned, policy string
+		_ = version.As(&incoming)
+		_ = priorVal["pinned_version"].As(&pinned)
+		_ = proposedVal["policy"].As(&policy)
+		if policy == "" {
+			policy = pinPolicyNone
+		}
+		if sameVersion(incoming, pinned) {
+			break
+		}
+		if pinUpgradeAllowed(policy, pinned, incoming) {
+			proposedVal["pinned_version"] = version
+			proposedVal["timestamp"] = tftypes.NewValue(tftypes.String, tftypes.UnknownValue)
+			break
+		}
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov5.Diagnostic{
+			Severity:  tfprotov5.DiagnosticSeverityWarning,
+			Summary:   "Version change not allowed by pin policy",
+			Detail:    fmt.Sprintf("Version %s is available, but policy %q does not allow moving from the pinned version %s. The pinned version is kept.", incoming, policy, pinned),
+			Attribute: tftypes.NewAttributePath().WithAttributeName("version"),
+		})
+	}
+}
+
+// applyVersionPin resolves whatever the plan left unknown.
+func (s *RawProviderServer) applyVersionPin(priorState tftypes.Value, plannedVal map[string]tftypes.Value) {
+	if !plannedVal["pinned_version"].IsKnown() {
+		plannedVal["pinned_version"] = plannedVal["version"]
+		if !priorState.IsNull() {
+			var incoming, pinned, policy string
+			priorVal := make(map[string]tftypes.Value)
+			_ = priorState.As(&priorVal)
+			_ = priorVal["pinned_version"].As(&pinned)
+			_ = plannedVal["version"].As(&incoming)
+			_ = plannedVal["policy"].As(&policy)
+			if sameVersion(incoming, pinned) || !pinUpgradeAllowed(policy, pinned, incoming) {
+				plannedVal["pinned_version"] = priorVal["pinned_version"]
+				plannedVal["timestamp"] = priorVal["timestamp"]
+			}
+		}
+	}
+	if !plannedVal["timestamp"].IsKnown() {
+		plannedVal["timestamp"] = tftypes.NewValue(tftypes.String, fmt.Sprint(time.Now().Unix()))
+	}
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestPinUpgradeAllowed(t *testing.T) {
+	cases := []struct {
+		policy, pinned, incoming string
+		want                     bool
+	}{
+		{pinPolicyPatch, "1.2.3", "1.2.4", true},
+		{pinPolicyPatch, "v1.2.3", "1.2.10", true},
+		{pinPolicyPatch, "1.2.3", "1.3.0", false},
+		{pinPolicyPatch, "1.2.3", "2.0.0", false},
+		{pinPolicyMinor, "1.2.3", "1.2.4", true},
+		{pinPolicyMinor, "1.2.3", "1.9.0", true},
+		{pinPolicyMinor, "1.2.3", "2.0.0", false},
+		{pinPolicyNone, "1.2.3", "1.2.4", false},
+		{"", "1.2.3", "1.2.4", false},
+
+		// downgrades and the same version are never upgrades
+		{pinPolicyPatch, "1.2.3", "1.2.2", false},
+		{pinPolicyMinor, "1.2.3", "1.1.9", false},
+		{pinPolicyMinor, "1.2.3", "v1.2.3", false},
+
+		// pre-releases sort before their release
+		{pinPolicyPatch, "1.2.3-rc.1", "1.2.3", true},
+		{pinPolicyPatch, "1.2.3", "1.2.4-rc.1", true},
+
+		{pinPolicyMinor, "1.2.3", "latest", false},
+		{pinPolicyMinor, "latest", "1.2.3", false},
+	}
+	for _, c := range cases {
+		if got := pinUpgradeAllowed(c.policy, c.pinned, c.incoming); got != c.want {
+			t.Errorf("policy %q from %s to %s: expected %t, got %t", c.policy, c.pinned, c.incoming, c.want, got)
+		}
+	}
+}
+
+func TestHarnessVersionPinProhibited(t *testing.T) {
+	cases := map[string]struct {
+		policy, incoming string
+	}{
+		"minor under patch": {pinPolicyPatch, "1.3.0"},
+		"major under minor": {pinPolicyMinor, "2.0.0"},
+		"patch under none":  {pinPolicyNone, "1.2.4"},
+		"downgrade":         {pinPolicyMinor, "1.2.2"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := newTestHarness(t, "cache_version_pin")
+			policy := tftypes.NewValue(tftypes.String, c.policy)
+			pinned := tftypes.NewValue(tftypes.String, "1.2.3")
+			assertNoDiagnostics(t, h.step(map[string]tftypes.Value{"version": pinned, "policy": policy}))
+			timestamp := h.state["timestamp"]
+
+			config := map[string]tftypes.Value{"version": tftypes.NewValue(tftypes.String, c.incoming), "policy": policy}
+			diags := h.step(config)
+			assertDiagnostic(t, diags, tfprotov5.DiagnosticSeverityWarning, "not allowed by pin policy")
+			if !strings.Contains(diags[0].Detail, c.incoming) {
+				t.Errorf("expected the warning to name version %s, got %q", c.incoming, diags[0].Detail)
+			}
+			if !h.state["pinned_version"].Equal(pinned) || !h.state["timestamp"].Equal(timestamp) {
+				t.Errorf("the pin should stay at %s, got %s", pinned, h.state["pinned_version"])
+			}
+			if !h.state["version"].Equal(config["version"]) {
+				t.Errorf("expected the incoming version to be recorded, got %s", h.state["version"])
+			}
+		})
+	}
+}
+
+func TestHarnessVersionPinPrefix(t *testing.T) {
+	h := newTestHarness(t, "cache_version_pin")
+	assertNoDiagnostics(t, h.step(map[string]tftypes.Value{
+		"version": tftypes.NewValue(tftypes.String, "1.2.3"),
+		"policy":  tftypes.NewValue(tftypes.String, pinPolicyNone),
+	}))
+
+	// The same version written with a "v" is not a version change.
+	config := map[string]tftypes.Value{
+		"version": tftypes.NewValue(tftypes.String, "v1.2.3"),
+		"policy":  tftypes.NewValue(tftypes.String, pinPolicyNone),
+	}
+	assertNoDiagnostics(t, h.step(config))
+	if !h.state["pinned_version"].Equal(tftypes.NewValue(tftypes.String, "1.2.3")) {
+		t.Errorf("the pinned version should be kept, got %s", h.state["pinned_version"])
+	}
+	h.assertNoChanges(config)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// inspectValueWidth is how much of a value the table shows before truncating it.
+const inspectValueWidth = 60
+
+// inspectEntry is a cache_store instance found in a state file.
+type inspectEntry struct {
+	Address     string          `json:"address"`
+	Value       json.RawMessage `json:"value"`
+	Captured    bool            `json:"captured"`
+	Fingerprint string          `json:"fingerprint,omitempty"`
+	Timestamp   string          `json:"timestamp,omitempty"`
+	CreatedAt   string          `json:"created_at,omitempty"`
+	AgeSeconds  *int64          `json:"age_seconds,omitempty"`
+}
+
+// stateFile is the part of a version 4 Terraform state file that holds resource instances.
+type stateFile struct {
+	Version   int `json:"version"`
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey   json.RawMessage            `json:"index_key"`
+			Attributes map[string]json.RawMessage `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// runInspect lists the cache_store instances of a state file, which is read from stdin when the path is "-".
+func runInspect(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	format := flags.String("format", "table", "output format, \"table\" or \"json\"")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: terraform-provider-cache inspect [-format table|json] STATE_FILE")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected a single state file, got %d arguments", flags.NArg())
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("format must be \"table\" or \"json\", got %q", *format)
+	}
+
+	in := stdin
+	if path := flags.Arg(0); path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	entries, err := inspectState(in, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+	return writeInspectTable(stdout, entries)
+}
+
+// inspectState decodes the cache_store instances of a state file, with their age as of now.
+func inspectState(r io.Reader, now time.Time) ([]inspectEntry, error) {
+	var state stateFile
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return nil, fmt.Errorf("decoding state: %w", err)
+	}
+	if state.Version != 4 {
+		return nil, fmt.Errorf("unsupported state version %d, only version 4 can be inspected", state.Version)
+	}
+
+	entries := []inspectEntry{}
+	for _, res := range state.Resources {
+		if res.Mode != "managed" || res.Type != "cache_store" {
+			continue
+		}
+		for _, inst := range res.Instances {
+			attrs := inst.Attributes
+			e := inspectEntry{
+				Address:  instanceAddress(res.Module, res.Type, res.Name, inst.IndexKey),
+				Value:    dynamicValue(attrs["value"]),
+				Captured: true,
+			}
+			// With 'frozen_paths' or 'type_constraint', the cached value is the one in 'result'.
+			if result := dynamicValue(attrs["result"]); string(result) != "null" {
+				e.Value = result
+			}
+			// Entries from before 'captured' existed have always captured their value.
+			_ = json.Unmarshal(attrs["captured"], &e.Captured)
+			_ = json.Unmarshal(attrs["fingerprint"], &e.Fingerprint)
+			_ = json.Unmarshal(attrs["timestamp"], &e.Timestamp)
+			_ = json.Unmarshal(attrs["created_at"], &e.CreatedAt)
+			if created, ok := entryCreatedAt(e); ok && e.Captured {
+				age := int64(now.Sub(created).Seconds())
+				e.AgeSeconds = &age
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// instanceAddress formats the address of a resource instance, such as module.app.cache_store.ami["eu"].
+func instanceAddress(module, typ, name string, indexKey json.RawMessage) string {
+	addr := typ + "." + name
+	if module != "" {
+		addr = module + "." + addr
+	}
+	if len(indexKey) > 0 && string(indexKey) != "null" {
+		addr += "[" + string(indexKey) + "]"
+	}
+	return addr
+}
+
+// dynamicValue unwraps and compacts a dynamically typed attribute, which the state records along with its type.
+func dynamicValue(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return json.RawMessage("null")
+	}
+	var typed struct {
+		Value json.RawMessage `json:"value"`
+		Type  json.RawMessage `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &typed); err == nil && typed.Value != nil && typed.Type != nil {
+		raw = typed.Value
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return raw
+	}
+	return buf.Bytes()
+}
+
+// entryCreatedAt returns when an entry captured its value, from 'created_at', or from a 'timestamp' in Unix seconds.
+func entryCreatedAt(e inspectEntry) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, e.CreatedAt); err == nil {
+		return t, true
+	}
+	if secs, err := strconv.ParseInt(e.Timestamp, 10, 64); err == nil {
+		return time.Unix(secs, 0), true
+	}
+	return time.Time{}, false
+}
+
+func writeInspectTable(w io.Writer, entries []inspectEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ADDRESS\tVALUE\tCAPTURED AT\tAGE")
+	for _, e := range entries {
+		value := string(e.Value)
+		if r := []rune(value); len(r) > inspectValueWidth {
+			value = string(r[:inspectValueWidth-3]) + "..."
+		}
+		capturedAt, age := "-", "-"
+		if !e.Captured {
+			value = "(pending)"
+		} else if created, ok := entryCreatedAt(e); ok {
+			capturedAt = created.UTC().Format(time.RFC3339)
+		}
+		if e.AgeSeconds != nil {
+			age = formatAge(time.Duration(*e.AgeSeconds) * time.Second)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Address, strings.ReplaceAll(value, "\t", " "), capturedAt, age)
+	}
+	return tw.Flush()
+}
+
+// formatAge formats an age in days and hours, or in minutes and seconds below a day.
+func formatAge(d time.Duration) string {
+	if d < 0 {
+		return "-"
+	}
+	if d >= 24*time.Hour {
+		days := d / (24 * time.Hour)
+		return fmt.Sprintf("%dd%dh", days, (d-days*24*time.Hour)/time.Hour)
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testState = `{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "cache_store",
+      "name": "ami",
+      "instances": [
+        {
+          "schema_version": 2,
+          "attributes": {
+            "value": {"value": "ami-0123", "type": "string"},
+            "captured": true,
+            "fingerprint": "abc",
+            "timestamp": "1700000000",
+            "created_at": "2023-11-14T22:13:20Z"
+          }
+        }
+      ]
+    },
+    {
+      "module": "module.app",
+      "mode": "managed",
+      "type": "cache_store",
+      "name": "tags",
+      "instances": [
+        {
+          "index_key": "eu",
+          "schema_version": 1,
+          "attributes": {
+            "value": {"value": {"owner": "dev"}, "type": ["object", {"owner": "string"}]},
+            "result": {"value": {"owner": "ops"}, "type": ["object", {"owner": "string"}]},
+            "timestamp": "1700000000"
+          }
+        },
+        {
+          "index_key": "us",
+          "schema_version": 2,
+          "attributes": {
+            "value": {"value": null, "type": "string"},
+            "captured": false,
+            "timestamp": "1700000000",
+            "created_at": "2023-11-14T22:13:20Z"
+          }
+        }
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "cache_version_pin",
+      "name": "app",
+      "instances": [{"attributes": {"version": "1.2.3"}}]
+    }
+  ]
+}`
+
+func TestInspectState(t *testing.T) {
+	now := time.Unix(1700000000, 0).Add(50 * time.Hour)
+	entries, err := inspectState(strings.NewReader(testState), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 cache_store instances, got %d", len(entries))
+	}
+
+	expected := []struct {
+		address, value string
+		captured       bool
+		age            int64
+	}{
+		{`cache_store.ami`, `"ami-0123"`, true, 50 * 3600},
+		{`module.app.cache_store.tags["eu"]`, `{"owner":"ops"}`, true, 50 * 3600},
+		{`module.app.cache_store.tags["us"]`, `null`, false, -1},
+	}
+	for i, e := range expected {
+		got := entries[i]
+		if got.Address != e.address || string(got.Value) != e.value || got.Captured != e.captured {
+			t.Errorf("entry %d: got %s = %s (captured %t), expected %s = %s (captured %t)", i, got.Address, got.Value, got.Captured, e.address, e.value, e.captured)
+		}
+		switch {
+		case e.age < 0 && got.AgeSeconds != nil:
+			t.Errorf("entry %d: a pending entry has no age, got %d", i, *got.AgeSeconds)
+		case e.age >= 0 && (got.AgeSeconds == nil || *got.AgeSeconds != e.age):
+			t.Errorf("entry %d: expected an age of %d, got %v", i, e.age, got.AgeSeconds)
+		}
+	}
+}
+
+func TestInspectStateVersion(t *testing.T) {
+	if _, err := inspectState(strings.NewReader(`{"version": 3}`), time.Now()); err == nil {
+		t.Error("state version 3 should not be inspected")
+	}
+}
+
+func TestRunInspect(t *testing.T) {
+	var out bytes.Buffer
+	if err := runInspect([]string{"-"}, strings.NewReader(testState), &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "ADDRESS") {
+		t.Fatalf("expected a header and 3 rows, got:\n%s", out.String())
+	}
+	if !strings.Contains(lines[1], `"ami-0123"`) || !strings.Contains(lines[1], "2023-11-14T22:13:20Z") {
+		t.Errorf("unexpected row: %s", lines[1])
+	}
+	if !strings.Contains(lines[3], "(pending)") {
+		t.Errorf("a pending entry should be shown as pending: %s", lines[3])
+	}
+
+	out.Reset()
+	if err := runInspect([]string{"-format", "json", "-"}, strings.NewReader(testState), &out); err != nil {
+		t.Fatal(err)
+	}
+	var entries []inspectEntry
+	if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[0].Fingerprint != "abc" {
+		t.Errorf("unexpected JSON output: %s", out.String())
+	}
+
+	if err := runInspect([]string{"-format", "yaml", "-"}, strings.NewReader(testState), &out); err == nil {
+		t.Error("an unknown format should fail")
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	cases := map[time.Duration]string{
+		90 * time.Second:               "1m30s",
+		50 * time.Hour:                 "2d2h",
+		400*24*time.Hour + 5*time.Hour: "400d5h",
+	}
+	for d, expected := range cases {
+		if got := formatAge(d); got != expected {
+			t.Errorf("formatAge(%s) = %q, expected %q", d, got, expected)
+		}
+	}
+}
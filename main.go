@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"os"
 
@@ -9,21 +10,41 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tf5server"
-	tfmux "github.com/hashicorp/terraform-plugin-mux"
+	"github.com/hashicorp/terraform-plugin-mux/tf5muxserver"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "inspect" {
+		if err := runInspect(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+			log.Println(err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	var debug bool
+	flag.BoolVar(&debug, "debug", false, "start the provider with support for debuggers like delve, printing the TF_REATTACH_PROVIDERS to run Terraform with")
+	flag.Parse()
+
+	var serveOpts []tf5server.ServeOpt
+	if debug {
+		serveOpts = append(serveOpts, tf5server.WithManagedDebug())
+	}
 
 	cache := cache.Provider()
 
 	ctx := context.Background()
-	factory, err := tfmux.NewSchemaServerFactory(ctx, cache)
+	muxServer, err := tf5muxserver.NewMuxServer(ctx, cache)
 	if err != nil {
 		log.Println(err.Error())
 		os.Exit(1)
 	}
 
-	tf5server.Serve("registry.terraform.io/massdriver-cloud/cache", func() tfprotov5.ProviderServer {
-		return factory.Server()
-	})
+	err = tf5server.Serve("registry.terraform.io/massdriver-cloud/cache", func() tfprotov5.ProviderServer {
+		return muxServer.ProviderServer()
+	}, serveOpts...)
+	if err != nil {
+		log.Println(err.Error())
+		os.Exit(1)
+	}
 }